@@ -0,0 +1,178 @@
+// Package cli is the go-schema-migrator command line tool. A program that
+// registers its own sources, processes or sinks runs the same tool by calling
+// Main after importing them.
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/knadh/koanf/parsers/yaml"
+	"github.com/knadh/koanf/providers/file"
+	"github.com/knadh/koanf/v2"
+	"go-schema-migrator/migration"
+	"go-schema-migrator/process"
+	"go-schema-migrator/registry"
+	"go-schema-migrator/sink"
+	"go-schema-migrator/source"
+	"go-schema-migrator/validation"
+	"log"
+	"maps"
+	"os"
+	"os/signal"
+	"slices"
+)
+
+func buildSource(config *koanf.Koanf, path string) (source.Source, error) {
+	sources, ok := config.Get(path).(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unable to build source - %v is missing or not a map", path)
+	}
+	if len(sources) != 1 {
+		return nil, fmt.Errorf("unable to build source - expected exactly one source type, found %v", len(sources))
+	}
+	sourceType := slices.Collect(maps.Keys(sources))[0]
+	return registry.NewSource(sourceType, config.Cut(fmt.Sprintf("%v.%v", path, sourceType)))
+}
+
+func buildProcesses(config *koanf.Koanf, path string) ([]process.Process, error) {
+
+	processes := make([]process.Process, 0)
+
+	s := config.Slices(path)
+	for _, conf := range s {
+		keys := slices.Collect(maps.Keys(conf.Raw()))
+		if len(keys) != 1 {
+			return nil, fmt.Errorf("unable to build process - expected exactly one process type, found %v", keys)
+		}
+		processType := keys[0]
+		p, err := registry.NewProcess(processType, conf.Cut(processType))
+		if err != nil {
+			return nil, fmt.Errorf("unable to build process %v: %w", processType, err)
+		}
+		processes = append(processes, p)
+	}
+
+	return processes, nil
+}
+
+func buildSink(config *koanf.Koanf, path string) (sink.Sink, error) {
+	sinks, ok := config.Get(path).(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unable to build sink - %v is missing or not a map", path)
+	}
+	if len(sinks) != 1 {
+		return nil, fmt.Errorf("unable to build sink - expected exactly one sink type, found %v", len(sinks))
+	}
+	sinkType := slices.Collect(maps.Keys(sinks))[0]
+	return registry.NewSink(sinkType, config.Cut(fmt.Sprintf("%v.%v", path, sinkType)))
+}
+
+// exitOnErrors writes out the report, and exits non-zero if it contains any
+// errors. Warnings alone don't stop the run.
+func exitOnErrors(config *koanf.Koanf, report *validation.Report) {
+	reportConfig := validation.ReportConfig{}
+	if config.Exists("report") {
+		err := config.Unmarshal("report", &reportConfig)
+		if err != nil {
+			log.Fatalf("unable to unmarshall report config: %v", err)
+		}
+	}
+	err := validation.WriteReport(reportConfig, report)
+	if err != nil {
+		log.Fatalf("unable to write report: %v", err)
+	}
+	if report.HasErrors() {
+		os.Exit(1)
+	}
+}
+
+// Main parses the command line flags, and runs the configured action. It
+// exits the program if the action fails, or finds errors.
+func Main() {
+
+	configFile := flag.String("config", "", "location of the config file to run")
+	list := flag.Bool("list", false, "list the available sources, processes and sinks, and their configuration")
+	flag.Parse()
+	if *list {
+		registry.List(os.Stdout)
+		return
+	}
+	if *configFile == "" {
+		_, err := fmt.Printf("Usage of %s:\n", os.Args[0])
+		if err != nil {
+			return
+		}
+		flag.PrintDefaults()
+		return
+	}
+
+	config := koanf.New(".")
+	if err := config.Load(file.Provider(*configFile), yaml.Parser()); err != nil {
+		log.Fatalf("error loading config: %v", err)
+	}
+
+	// An interrupted migration still puts back the modes it changed
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	switch action := config.String("action"); action {
+	case "migrate":
+		src, err := buildSource(config, "source")
+		if err != nil {
+			log.Fatalf("unable to build source: %v", err)
+		}
+		processes, err := buildProcesses(config, "processes")
+		if err != nil {
+			log.Fatal(err)
+		}
+		snk, err := buildSink(config, "sink")
+		if err != nil {
+			log.Fatalf("unable to build sink: %v", err)
+		}
+
+		report, err := migration.Migrate(ctx, src, processes, snk)
+		if err != nil {
+			log.Fatalf("migration failed: %v", err)
+		}
+		exitOnErrors(config, report)
+
+	case "validate":
+		sourceA, err := buildSource(config, "sourceA")
+		if err != nil {
+			log.Fatalf("unable to build sourceA: %v", err)
+		}
+		sourceB, err := buildSource(config, "sourceB")
+		if err != nil {
+			log.Fatalf("unable to build sourceB: %v", err)
+		}
+
+		options := migration.ValidateOptions{}
+
+		// IDs remapped during the migration are compared as they were remapped
+		if config.Exists("id_mapping") {
+			options.IDMapping, err = process.ReadIDMapping(config.String("id_mapping"))
+			if err != nil {
+				log.Fatal(err)
+			}
+		}
+
+		// Schemas are compared in canonical form, if the registries format them differently
+		if config.Exists("normalize") {
+			options.Normalize = &process.NormalizeSchemasProcess{}
+			err = config.Unmarshal("normalize", options.Normalize)
+			if err != nil {
+				log.Fatalf("unable to unmarshall normalize config: %v", err)
+			}
+		}
+
+		report, err := migration.Validate(ctx, sourceA, sourceB, options)
+		if err != nil {
+			log.Fatalf("validation failed: %v", err)
+		}
+		exitOnErrors(config, report)
+
+	default:
+		log.Fatalf("unknown action: %q", action)
+	}
+}
@@ -0,0 +1,118 @@
+// Package client holds what the sources and sinks share in talking to schema
+// registries and Kafka clusters: retries, authentication and the records of a
+// _schemas topic
+package client
+
+import (
+	"crypto/tls"
+	"fmt"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/sasl/aws"
+	"github.com/twmb/franz-go/pkg/sasl/plain"
+	"github.com/twmb/franz-go/pkg/sasl/scram"
+	"github.com/twmb/tlscfg"
+	"strings"
+)
+
+type SASLConfig struct {
+	Mechanism string `koanf:"mechanism"`
+	Username  string `koanf:"username"`
+	Password  string `koanf:"password"`
+}
+
+type TLSConfig struct {
+	Enabled        bool   `koanf:"enabled"`
+	ClientKeyFile  string `koanf:"client_key"`
+	ClientCertFile string `koanf:"client_cert"`
+	CaFile         string `koanf:"ca_cert"`
+}
+
+// SASLOpt Initializes the necessary SASL configuration options
+func SASLOpt(config *SASLConfig, opts []kgo.Opt) ([]kgo.Opt, error) {
+	if config.Mechanism != "" ||
+		config.Username != "" ||
+		config.Password != "" {
+
+		if config.Mechanism == "" ||
+			config.Username == "" ||
+			config.Password == "" {
+			return nil, fmt.Errorf("all of mechanism, username, password " +
+				"must be specified if any are")
+		}
+		mechanism := strings.ToLower(config.Mechanism)
+		mechanism = strings.ReplaceAll(mechanism, "-", "")
+		mechanism = strings.ReplaceAll(mechanism, "_", "")
+		switch mechanism {
+		case "plain":
+			opts = append(opts, kgo.SASL(plain.Auth{
+				User: config.Username,
+				Pass: config.Password,
+			}.AsMechanism()))
+		case "scramsha256":
+			opts = append(opts, kgo.SASL(scram.Auth{
+				User: config.Username,
+				Pass: config.Password,
+			}.AsSha256Mechanism()))
+		case "scramsha512":
+			opts = append(opts, kgo.SASL(scram.Auth{
+				User: config.Username,
+				Pass: config.Password,
+			}.AsSha512Mechanism()))
+		case "awsmskiam":
+			opts = append(opts, kgo.SASL(aws.Auth{
+				AccessKey: config.Username,
+				SecretKey: config.Password,
+			}.AsManagedStreamingIAMMechanism()))
+		default:
+			return nil, fmt.Errorf("unrecognized sasl mechanism: %s", mechanism)
+		}
+	}
+	return opts, nil
+}
+
+func TLSOpt(tlsConfig *TLSConfig, opts []kgo.Opt) ([]kgo.Opt, error) {
+	if tlsConfig.Enabled {
+		if tlsConfig.CaFile != "" ||
+			tlsConfig.ClientCertFile != "" ||
+			tlsConfig.ClientKeyFile != "" {
+			tc, err := tlscfg.New(
+				tlscfg.MaybeWithDiskCA(
+					tlsConfig.CaFile, tlscfg.ForClient),
+				tlscfg.MaybeWithDiskKeyPair(
+					tlsConfig.ClientCertFile, tlsConfig.ClientKeyFile),
+			)
+			if err != nil {
+				return nil, fmt.Errorf("unable to create TLS config: %w", err)
+			}
+			opts = append(opts, kgo.DialTLSConfig(tc))
+		} else {
+			opts = append(opts, kgo.DialTLSConfig(new(tls.Config)))
+		}
+	}
+	return opts, nil
+}
+
+// KafkaOpts Builds the client options for the seed brokers, including any SASL
+// and TLS configuration
+func KafkaOpts(seed string, saslConfig *SASLConfig, tlsConfig *TLSConfig) ([]kgo.Opt, error) {
+	opts := make([]kgo.Opt, 0)
+
+	opts = append(opts, kgo.SeedBrokers(seed))
+
+	var err error
+	if saslConfig != nil {
+		opts, err = SASLOpt(saslConfig, opts)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if tlsConfig != nil {
+		opts, err = TLSOpt(tlsConfig, opts)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return opts, nil
+}
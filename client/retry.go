@@ -0,0 +1,115 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/twmb/franz-go/pkg/sr"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+type RetryConfig struct {
+	Attempts       int           `koanf:"attempts"`
+	InitialBackoff time.Duration `koanf:"initial_backoff"`
+	MaxBackoff     time.Duration `koanf:"max_backoff"`
+	Timeout        time.Duration `koanf:"timeout"`
+}
+
+func (c RetryConfig) withDefaults() RetryConfig {
+	if c.Attempts < 1 {
+		c.Attempts = 5
+	}
+	if c.InitialBackoff <= 0 {
+		c.InitialBackoff = 250 * time.Millisecond
+	}
+	if c.MaxBackoff <= 0 {
+		c.MaxBackoff = 10 * time.Second
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = 30 * time.Second
+	}
+	return c
+}
+
+// backoff returns how long to wait before the given retry, using exponential
+// backoff with full jitter
+func (c RetryConfig) backoff(retry int) time.Duration {
+	ceiling := c.MaxBackoff
+	if retry < 32 {
+		ceiling = min(c.InitialBackoff<<retry, c.MaxBackoff)
+	}
+	return rand.N(ceiling) + 1
+}
+
+// isRetryable reports whether a request that failed with err is worth trying
+// again: server errors, throttling, timeouts and dropped or refused
+// connections are, while any other error, such as a client error, a bad URL or
+// a TLS failure, will fail the same way every time
+func isRetryable(err error) bool {
+	var responseError *sr.ResponseError
+	if errors.As(err, &responseError) {
+		return responseError.StatusCode >= 500 || responseError.StatusCode == http.StatusTooManyRequests
+	}
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) {
+		return true
+	}
+	// Every *url.Error is a net.Error, so only its timeout is telling
+	var netError net.Error
+	if errors.As(err, &netError) && netError.Timeout() {
+		return true
+	}
+	return errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET)
+}
+
+// IsNotFound reports whether a request failed because the subject, version or
+// schema doesn't exist
+func IsNotFound(err error) bool {
+	var responseError *sr.ResponseError
+	return errors.As(err, &responseError) && responseError.StatusCode == http.StatusNotFound
+}
+
+// ModeErrors joins the errors of any mode results that failed
+func ModeErrors(results []sr.ModeResult) error {
+	errs := make([]error, 0)
+	for _, result := range results {
+		if result.Err != nil {
+			errs = append(errs, fmt.Errorf("subject %q: %w", result.Subject, result.Err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Retry calls fn until it succeeds, fails with an error that isn't retryable,
+// or runs out of attempts. Each attempt gets its own timeout.
+func Retry[T any](ctx context.Context, policy RetryConfig, fn func(context.Context) (T, error)) (T, error) {
+	policy = policy.withDefaults()
+
+	var result T
+	var err error
+	for attempt := 0; attempt < policy.Attempts; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return result, errors.Join(err, ctx.Err())
+			case <-time.After(policy.backoff(attempt - 1)):
+			}
+		}
+
+		attemptCtx, cancel := context.WithTimeout(ctx, policy.Timeout)
+		result, err = fn(attemptCtx)
+		cancel()
+
+		if err == nil || !isRetryable(err) || ctx.Err() != nil {
+			return result, err
+		}
+	}
+	return result, fmt.Errorf("giving up after %v attempts: %w", policy.Attempts, err)
+}
@@ -0,0 +1,101 @@
+package client
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/sr"
+)
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func urlError(err error) error {
+	return &url.Error{Op: "Get", URL: "https://registry:8081/subjects", Err: err}
+}
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "server error", err: &sr.ResponseError{StatusCode: http.StatusBadGateway}, want: true},
+		{name: "throttled", err: &sr.ResponseError{StatusCode: http.StatusTooManyRequests}, want: true},
+		{name: "client error", err: &sr.ResponseError{StatusCode: http.StatusUnprocessableEntity}},
+		{name: "not found", err: &sr.ResponseError{StatusCode: http.StatusNotFound}},
+		{name: "cancelled", err: context.Canceled},
+		{name: "attempt timed out", err: urlError(context.DeadlineExceeded), want: true},
+		{name: "network timeout", err: urlError(&net.OpError{Op: "dial", Net: "tcp", Err: timeoutError{}}), want: true},
+		{name: "connection refused", err: urlError(&net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}), want: true},
+		{name: "connection reset", err: urlError(&net.OpError{Op: "read", Net: "tcp", Err: os.NewSyscallError("read", syscall.ECONNRESET)}), want: true},
+		{name: "connection closed", err: urlError(io.EOF), want: true},
+		{name: "connection closed early", err: urlError(io.ErrUnexpectedEOF), want: true},
+		{name: "unknown certificate authority", err: urlError(x509.UnknownAuthorityError{})},
+		{name: "certificate for another host", err: urlError(x509.HostnameError{Host: "registry"})},
+		{name: "bad url", err: urlError(errors.New(`unsupported protocol scheme "htp"`))},
+		{name: "unknown host", err: urlError(&net.OpError{Op: "dial", Net: "tcp", Err: &net.DNSError{Err: "no such host", Name: "registry", IsNotFound: true}})},
+		{name: "other error", err: errors.New("unable to decode response")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isRetryable(fmt.Errorf("request failed: %w", tt.err)); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryTLSFailure(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	// The server's certificate isn't trusted, which no retry will fix
+	attempts := 0
+	policy := RetryConfig{Attempts: 3, InitialBackoff: time.Millisecond}
+	_, err := Retry(context.Background(), policy, func(ctx context.Context) (*http.Response, error) {
+		attempts++
+		request, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+		if err != nil {
+			return nil, err
+		}
+		response, err := http.DefaultClient.Do(request)
+		if err == nil {
+			response.Body.Close()
+		}
+		return response, err
+	})
+	if err == nil {
+		t.Fatal("expected a certificate error")
+	}
+	if attempts != 1 {
+		t.Errorf("got %v attempts, want 1", attempts)
+	}
+}
+
+func TestRetryGivesUp(t *testing.T) {
+	attempts := 0
+	policy := RetryConfig{Attempts: 3, InitialBackoff: time.Millisecond}
+	_, err := Retry(context.Background(), policy, func(ctx context.Context) (int, error) {
+		attempts++
+		return 0, &sr.ResponseError{StatusCode: http.StatusServiceUnavailable}
+	})
+	if err == nil || attempts != 3 {
+		t.Errorf("got %v after %v attempts, want an error after 3", err, attempts)
+	}
+}
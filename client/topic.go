@@ -0,0 +1,29 @@
+package client
+
+import (
+	"github.com/twmb/franz-go/pkg/sr"
+)
+
+// TopicKey is the key of a record in a _schemas topic
+type TopicKey struct {
+	KeyType string `json:"keytype"`
+	Subject string `json:"subject"`
+	Version int    `json:"version"`
+}
+
+// TopicSchemaValue is the value of a SCHEMA record in a _schemas topic
+type TopicSchemaValue struct {
+	sr.SubjectSchema
+	Deleted bool `json:"deleted"`
+}
+
+// TopicDeleteSubjectValue is the value of a DELETE_SUBJECT record in a _schemas topic
+type TopicDeleteSubjectValue struct {
+	Subject string `json:"subject"`
+	Version int    `json:"version"`
+}
+
+// TopicModeValue is the value of a MODE record in a _schemas topic
+type TopicModeValue struct {
+	Mode sr.Mode `json:"mode"`
+}
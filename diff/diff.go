@@ -0,0 +1,220 @@
+package diff
+
+import (
+	"github.com/twmb/franz-go/pkg/sr"
+	"go-schema-migrator/internal/collections"
+	"go-schema-migrator/state"
+	"go-schema-migrator/validation"
+	"maps"
+	"reflect"
+	"slices"
+)
+
+func diffSubjectSchemas(report *validation.Report, a sr.SubjectSchema, b sr.SubjectSchema) {
+	if a.ID != b.ID {
+		report.Differs("id_mismatch", a.Subject, a.Version, "schema IDs don't match", a.ID, b.ID)
+	}
+	if a.Type != b.Type {
+		report.Differs("type_mismatch", a.Subject, a.Version, "schema types don't match", a.Type, b.Type)
+	}
+	if a.Schema.Schema != b.Schema.Schema {
+		report.Differs("schema_mismatch", a.Subject, a.Version, "schemas don't match", a.Schema.Schema, b.Schema.Schema)
+	}
+	// Sources disagree on whether no references is nil or empty
+	if (len(a.References) > 0 || len(b.References) > 0) && !reflect.DeepEqual(a.References, b.References) {
+		report.Differs("references_mismatch", a.Subject, a.Version, "references don't match", validation.Describe(a.References), validation.Describe(b.References))
+	}
+	if !state.Equivalent(a.SchemaMetadata, b.SchemaMetadata) {
+		report.Differs("metadata_mismatch", a.Subject, a.Version, "metadata doesn't match", validation.Describe(a.SchemaMetadata), validation.Describe(b.SchemaMetadata))
+	}
+	if !state.Equivalent(a.SchemaRuleSet, b.SchemaRuleSet) {
+		report.Differs("ruleset_mismatch", a.Subject, a.Version, "rule sets don't match", validation.Describe(a.SchemaRuleSet), validation.Describe(b.SchemaRuleSet))
+	}
+}
+
+// diffCompatibilityResults compares everything in a subject (or global)
+// configuration besides its compatibility level
+func diffCompatibilityResults(report *validation.Report, subject string, a sr.CompatibilityResult, b sr.CompatibilityResult) {
+	if a.Alias != b.Alias {
+		report.Differs("alias_mismatch", subject, 0, "aliases don't match", a.Alias, b.Alias)
+	}
+	if a.Normalize != b.Normalize {
+		report.Differs("normalize_mismatch", subject, 0, "normalize flags don't match", a.Normalize, b.Normalize)
+	}
+	if a.Group != b.Group {
+		report.Differs("compatibility_group_mismatch", subject, 0, "compatibility groups don't match", a.Group, b.Group)
+	}
+	if !state.Equivalent(a.DefaultMetadata, b.DefaultMetadata) {
+		report.Differs("default_metadata_mismatch", subject, 0, "default metadata doesn't match", validation.Describe(a.DefaultMetadata), validation.Describe(b.DefaultMetadata))
+	}
+	if !state.Equivalent(a.OverrideMetadata, b.OverrideMetadata) {
+		report.Differs("override_metadata_mismatch", subject, 0, "override metadata doesn't match", validation.Describe(a.OverrideMetadata), validation.Describe(b.OverrideMetadata))
+	}
+	if !state.Equivalent(a.DefaultRuleSet, b.DefaultRuleSet) {
+		report.Differs("default_ruleset_mismatch", subject, 0, "default rule sets don't match", validation.Describe(a.DefaultRuleSet), validation.Describe(b.DefaultRuleSet))
+	}
+	if !state.Equivalent(a.OverrideRuleSet, b.OverrideRuleSet) {
+		report.Differs("override_ruleset_mismatch", subject, 0, "override rule sets don't match", validation.Describe(a.OverrideRuleSet), validation.Describe(b.OverrideRuleSet))
+	}
+}
+
+// Diff compares two states, reporting every difference between the left (a)
+// and the right (b)
+func Diff(a *state.State, b *state.State) *validation.Report {
+
+	report := validation.NewReport()
+
+	// Build Lookup Tables
+
+	aSubjectSchemas := make(map[sr.SubjectVersion]sr.SubjectSchema)
+	for _, subjectSchema := range a.SubjectSchemas {
+		aSubjectSchemas[state.GetReference(subjectSchema)] = subjectSchema
+	}
+
+	bSubjectSchemas := make(map[sr.SubjectVersion]sr.SubjectSchema)
+	for _, subjectSchema := range b.SubjectSchemas {
+		bSubjectSchemas[state.GetReference(subjectSchema)] = subjectSchema
+	}
+
+	aCompatibilityResults := make(map[string]sr.CompatibilityResult)
+	for _, compatibilityResult := range a.CompatibilityResults {
+		aCompatibilityResults[compatibilityResult.Subject] = compatibilityResult
+	}
+
+	bCompatibilityResults := make(map[string]sr.CompatibilityResult)
+	for _, compatibilityResult := range b.CompatibilityResults {
+		bCompatibilityResults[compatibilityResult.Subject] = compatibilityResult
+	}
+
+	aSoftDeletions := collections.ToMap(a.SoftDeletions)
+	bSoftDeletions := collections.ToMap(b.SoftDeletions)
+
+	// Compare subject schemas
+
+	for _, aSubjectSchema := range a.SubjectSchemas {
+		bSubjectSchema, ok := bSubjectSchemas[state.GetReference(aSubjectSchema)]
+		if !ok {
+			report.Errorf("missing_right", aSubjectSchema.Subject, aSubjectSchema.Version, "not found in right")
+			continue
+		}
+		diffSubjectSchemas(report, aSubjectSchema, bSubjectSchema)
+	}
+
+	for _, bSubjectSchema := range b.SubjectSchemas {
+		_, ok := aSubjectSchemas[state.GetReference(bSubjectSchema)]
+		if !ok {
+			report.Errorf("missing_left", bSubjectSchema.Subject, bSubjectSchema.Version, "not found in left")
+		}
+	}
+
+	// Compare compatibility levels
+
+	subjects := collections.ToMap(slices.Collect(maps.Keys(aCompatibilityResults)))
+	maps.Copy(subjects, collections.ToMap(slices.Collect(maps.Keys(bCompatibilityResults))))
+	for _, subject := range slices.Sorted(maps.Keys(subjects)) {
+		aResult, aOk := aCompatibilityResults[subject]
+		bResult, bOk := bCompatibilityResults[subject]
+		switch {
+		case !bOk:
+			report.Errorf("compatibility_missing_right", subject, 0, "compatibility level %v not found in right", aResult.Level)
+		case !aOk:
+			report.Errorf("compatibility_missing_left", subject, 0, "compatibility level %v not found in left", bResult.Level)
+		case aResult.Level != bResult.Level:
+			report.Differs("compatibility_mismatch", subject, 0, "compatibility levels don't match", aResult.Level, bResult.Level)
+		}
+		if aOk && bOk {
+			diffCompatibilityResults(report, subject, aResult, bResult)
+		}
+	}
+
+	// Compare global configuration
+
+	if a.GlobalCompatibility != nil || b.GlobalCompatibility != nil {
+		var aLevel, bLevel any = "(none)", "(none)"
+		if a.GlobalCompatibility != nil {
+			aLevel = a.GlobalCompatibility.Level
+		}
+		if b.GlobalCompatibility != nil {
+			bLevel = b.GlobalCompatibility.Level
+		}
+		if aLevel != bLevel {
+			report.Differs("global_compatibility_mismatch", "", 0, "global compatibility levels don't match", aLevel, bLevel)
+		}
+		if a.GlobalCompatibility != nil && b.GlobalCompatibility != nil {
+			diffCompatibilityResults(report, "", *a.GlobalCompatibility, *b.GlobalCompatibility)
+		}
+	}
+
+	if a.GlobalMode != nil || b.GlobalMode != nil {
+		var aMode, bMode any = "(none)", "(none)"
+		if a.GlobalMode != nil {
+			aMode = *a.GlobalMode
+		}
+		if b.GlobalMode != nil {
+			bMode = *b.GlobalMode
+		}
+		if aMode != bMode {
+			report.Differs("global_mode_mismatch", "", 0, "global modes don't match", aMode, bMode)
+		}
+	}
+
+	// Compare subject modes
+
+	aModes := make(map[string]sr.Mode)
+	for _, result := range a.Modes {
+		aModes[result.Subject] = result.Mode
+	}
+
+	bModes := make(map[string]sr.Mode)
+	for _, result := range b.Modes {
+		bModes[result.Subject] = result.Mode
+	}
+
+	subjects = collections.ToMap(slices.Collect(maps.Keys(aModes)))
+	maps.Copy(subjects, collections.ToMap(slices.Collect(maps.Keys(bModes))))
+	for _, subject := range slices.Sorted(maps.Keys(subjects)) {
+		aMode, aOk := aModes[subject]
+		bMode, bOk := bModes[subject]
+		switch {
+		case !bOk:
+			report.Errorf("mode_missing_right", subject, 0, "mode %v not found in right", aMode)
+		case !aOk:
+			report.Errorf("mode_missing_left", subject, 0, "mode %v not found in left", bMode)
+		case aMode != bMode:
+			report.Differs("mode_mismatch", subject, 0, "modes don't match", aMode, bMode)
+		}
+	}
+
+	// Compare soft deletions
+
+	for _, deletion := range a.SoftDeletions {
+		if !bSoftDeletions[deletion] {
+			report.Errorf("soft_deletion_missing_right", deletion.Subject, deletion.Version, "soft deleted in left but not in right")
+		}
+	}
+
+	for _, deletion := range b.SoftDeletions {
+		if !aSoftDeletions[deletion] {
+			report.Errorf("soft_deletion_missing_left", deletion.Subject, deletion.Version, "soft deleted in right but not in left")
+		}
+	}
+
+	// Compare subject deletions
+
+	aDeletedSubjects := collections.ToMap(a.DeletedSubjects)
+	bDeletedSubjects := collections.ToMap(b.DeletedSubjects)
+
+	for _, subject := range a.DeletedSubjects {
+		if !bDeletedSubjects[subject] {
+			report.Errorf("deleted_subject_missing_right", subject, 0, "subject deleted in left but not in right")
+		}
+	}
+
+	for _, subject := range b.DeletedSubjects {
+		if !aDeletedSubjects[subject] {
+			report.Errorf("deleted_subject_missing_left", subject, 0, "subject deleted in right but not in left")
+		}
+	}
+
+	return report
+}
@@ -0,0 +1,248 @@
+package diff
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/twmb/franz-go/pkg/sr"
+	"go-schema-migrator/state"
+)
+
+func baseState() *state.State {
+	mode := sr.ModeReadWrite
+	return &state.State{
+		SubjectSchemas: []sr.SubjectSchema{
+			{Subject: "a", Version: 1, ID: 1, Schema: sr.Schema{Schema: `"string"`}},
+			{Subject: "b", Version: 1, ID: 2, Schema: sr.Schema{
+				Schema:     `"int"`,
+				References: []sr.SchemaReference{{Name: "a", Subject: "a", Version: 1}},
+			}},
+		},
+		CompatibilityResults: []sr.CompatibilityResult{{Subject: "a", Level: sr.CompatFull}},
+		SoftDeletions:        []sr.SubjectVersion{{Subject: "b", Version: 1}},
+		DeletedSubjects:      []string{"b"},
+		GlobalCompatibility:  &sr.CompatibilityResult{Level: sr.CompatBackward},
+		GlobalMode:           &mode,
+		Modes:                []sr.ModeResult{{Subject: "a", Mode: sr.ModeReadOnly}},
+	}
+}
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(s *state.State)
+		want   []string
+	}{
+		{
+			name:   "identical states",
+			change: func(s *state.State) {},
+			want:   []string{},
+		},
+		{
+			name: "empty and missing values are the same",
+			change: func(s *state.State) {
+				s.SubjectSchemas[0].References = []sr.SchemaReference{}
+				s.SubjectSchemas[0].SchemaMetadata = &sr.SchemaMetadata{}
+				s.SubjectSchemas[0].SchemaRuleSet = &sr.SchemaRuleSet{}
+			},
+			want: []string{},
+		},
+		{
+			name: "schema missing",
+			change: func(s *state.State) {
+				s.SubjectSchemas = s.SubjectSchemas[:1]
+			},
+			want: []string{"missing_right"},
+		},
+		{
+			name: "schema added",
+			change: func(s *state.State) {
+				s.SubjectSchemas = append(s.SubjectSchemas, sr.SubjectSchema{Subject: "c", Version: 1, ID: 3, Schema: sr.Schema{Schema: `"long"`}})
+			},
+			want: []string{"missing_left"},
+		},
+		{
+			name: "id",
+			change: func(s *state.State) {
+				s.SubjectSchemas[0].ID = 10
+			},
+			want: []string{"id_mismatch"},
+		},
+		{
+			name: "type",
+			change: func(s *state.State) {
+				s.SubjectSchemas[0].Type = sr.TypeJSON
+			},
+			want: []string{"type_mismatch"},
+		},
+		{
+			name: "schema",
+			change: func(s *state.State) {
+				s.SubjectSchemas[0].Schema.Schema = `"bytes"`
+			},
+			want: []string{"schema_mismatch"},
+		},
+		{
+			name: "references",
+			change: func(s *state.State) {
+				s.SubjectSchemas[1].References = nil
+			},
+			want: []string{"references_mismatch"},
+		},
+		{
+			name: "metadata",
+			change: func(s *state.State) {
+				s.SubjectSchemas[0].SchemaMetadata = &sr.SchemaMetadata{Properties: map[string]string{"owner": "team"}}
+			},
+			want: []string{"metadata_mismatch"},
+		},
+		{
+			name: "rule set",
+			change: func(s *state.State) {
+				s.SubjectSchemas[0].SchemaRuleSet = &sr.SchemaRuleSet{DomainRules: []sr.SchemaRule{{Name: "rule", Kind: sr.SchemaRuleKindCondition}}}
+			},
+			want: []string{"ruleset_mismatch"},
+		},
+		{
+			name: "compatibility level",
+			change: func(s *state.State) {
+				s.CompatibilityResults[0].Level = sr.CompatNone
+			},
+			want: []string{"compatibility_mismatch"},
+		},
+		{
+			name: "compatibility level missing",
+			change: func(s *state.State) {
+				s.CompatibilityResults = nil
+			},
+			want: []string{"compatibility_missing_right"},
+		},
+		{
+			name: "compatibility level added",
+			change: func(s *state.State) {
+				s.CompatibilityResults = append(s.CompatibilityResults, sr.CompatibilityResult{Subject: "b", Level: sr.CompatNone})
+			},
+			want: []string{"compatibility_missing_left"},
+		},
+		{
+			name: "default and override metadata and rule sets",
+			change: func(s *state.State) {
+				metadata := &sr.SchemaMetadata{Properties: map[string]string{"owner": "team"}}
+				ruleSet := &sr.SchemaRuleSet{DomainRules: []sr.SchemaRule{{Name: "rule", Kind: sr.SchemaRuleKindCondition}}}
+				s.CompatibilityResults[0].DefaultMetadata = metadata
+				s.CompatibilityResults[0].OverrideMetadata = metadata
+				s.CompatibilityResults[0].DefaultRuleSet = ruleSet
+				s.CompatibilityResults[0].OverrideRuleSet = ruleSet
+			},
+			want: []string{"default_metadata_mismatch", "default_ruleset_mismatch", "override_metadata_mismatch", "override_ruleset_mismatch"},
+		},
+		{
+			name: "empty default metadata is the same as none",
+			change: func(s *state.State) {
+				s.CompatibilityResults[0].DefaultMetadata = &sr.SchemaMetadata{}
+			},
+			want: []string{},
+		},
+		{
+			name: "alias, normalize and compatibility group",
+			change: func(s *state.State) {
+				s.CompatibilityResults[0].Alias = "b"
+				s.CompatibilityResults[0].Normalize = true
+				s.CompatibilityResults[0].Group = "application.version"
+			},
+			want: []string{"alias_mismatch", "compatibility_group_mismatch", "normalize_mismatch"},
+		},
+		{
+			name: "global compatibility level",
+			change: func(s *state.State) {
+				s.GlobalCompatibility = nil
+			},
+			want: []string{"global_compatibility_mismatch"},
+		},
+		{
+			name: "global configuration",
+			change: func(s *state.State) {
+				s.GlobalCompatibility.Normalize = true
+			},
+			want: []string{"normalize_mismatch"},
+		},
+		{
+			name: "global mode",
+			change: func(s *state.State) {
+				mode := sr.ModeImport
+				s.GlobalMode = &mode
+			},
+			want: []string{"global_mode_mismatch"},
+		},
+		{
+			name: "mode",
+			change: func(s *state.State) {
+				s.Modes[0].Mode = sr.ModeImport
+			},
+			want: []string{"mode_mismatch"},
+		},
+		{
+			name: "mode missing",
+			change: func(s *state.State) {
+				s.Modes = nil
+			},
+			want: []string{"mode_missing_right"},
+		},
+		{
+			name: "mode added",
+			change: func(s *state.State) {
+				s.Modes = append(s.Modes, sr.ModeResult{Subject: "b", Mode: sr.ModeImport})
+			},
+			want: []string{"mode_missing_left"},
+		},
+		{
+			name: "soft deletion missing",
+			change: func(s *state.State) {
+				s.SoftDeletions = nil
+				s.DeletedSubjects = nil
+			},
+			want: []string{"deleted_subject_missing_right", "soft_deletion_missing_right"},
+		},
+		{
+			name: "soft deletion added",
+			change: func(s *state.State) {
+				s.SoftDeletions = append(s.SoftDeletions, sr.SubjectVersion{Subject: "a", Version: 1})
+				s.DeletedSubjects = append(s.DeletedSubjects, "a")
+			},
+			want: []string{"deleted_subject_missing_left", "soft_deletion_missing_left"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := baseState()
+			tt.change(b)
+			got := make([]string, 0)
+			for _, problem := range Diff(baseState(), b).Problems {
+				got = append(got, problem.Check)
+			}
+			slices.Sort(got)
+			slices.Sort(tt.want)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffValues(t *testing.T) {
+	b := baseState()
+	b.SubjectSchemas[0].ID = 10
+	b.GlobalCompatibility = nil
+
+	problems := Diff(baseState(), b).Problems
+	if len(problems) != 2 {
+		t.Fatalf("got %v problems, want 2", len(problems))
+	}
+	if problems[0].Subject != "a" || problems[0].Version != 1 || problems[0].Left != "1" || problems[0].Right != "10" {
+		t.Errorf("unexpected id problem: %+v", problems[0])
+	}
+	if problems[1].Left != "BACKWARD" || problems[1].Right != "(none)" {
+		t.Errorf("unexpected global compatibility problem: %+v", problems[1])
+	}
+}
@@ -0,0 +1,21 @@
+// Package collections holds the slice helpers shared by the other packages
+package collections
+
+// Filter returns the items of ss that pass test
+func Filter[T any](ss []T, test func(T) bool) (ret []T) {
+	for _, s := range ss {
+		if test(s) {
+			ret = append(ret, s)
+		}
+	}
+	return
+}
+
+// ToMap returns the set of items in ss
+func ToMap[T comparable](ss []T) map[T]bool {
+	result := make(map[T]bool)
+	for _, s := range ss {
+		result[s] = true
+	}
+	return result
+}
@@ -0,0 +1,121 @@
+// Package migration runs a state from a source through processes into a sink,
+// and compares the states of two registries once they have been migrated
+package migration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-schema-migrator/diff"
+	"go-schema-migrator/process"
+	"go-schema-migrator/sink"
+	"go-schema-migrator/source"
+	"go-schema-migrator/state"
+	"go-schema-migrator/validation"
+)
+
+// Migrate reads the state from src, runs it through each process in turn and
+// writes it to snk. Problems found along the way are collected in the report,
+// and if any are errors the sink isn't written. An error is only returned if
+// the migration couldn't be carried out at all.
+func Migrate(ctx context.Context, src source.Source, processes []process.Process, snk sink.Sink) (*validation.Report, error) {
+	s, err := src.GetState(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read state: %w", err)
+	}
+
+	report := validation.NewReport()
+	err = s.Sort()
+	if err != nil {
+		report.Errorf("reference_cycle", "", 0, "%v", err)
+	}
+	report.Merge(s.Validate())
+
+	// Processes only run against a valid state, and each must leave it valid
+	for _, p := range processes {
+		if report.HasErrors() {
+			break
+		}
+		s, err = p.Process(ctx, s)
+		var processReport *validation.Report
+		if errors.As(err, &processReport) {
+			report.Merge(processReport)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if reporter, ok := p.(validation.Reporter); ok {
+			report.Merge(reporter.Report())
+		}
+		report.Merge(s.Validate())
+	}
+
+	if report.HasErrors() {
+		return report, nil
+	}
+
+	err = snk.PutState(ctx, s)
+	if err != nil {
+		return nil, fmt.Errorf("unable to write state: %w", err)
+	}
+	if reporter, ok := snk.(validation.Reporter); ok {
+		report.Merge(reporter.Report())
+	}
+	return report, nil
+}
+
+// ValidateOptions adjusts how the states are compared
+type ValidateOptions struct {
+	// IDMapping holds the new ID of each schema ID remapped in the migration,
+	// so that the left is compared as it was remapped
+	IDMapping map[state.ContextID]int
+	// Normalize, if set, rewrites the schemas of both states into canonical
+	// form, for registries that format them differently
+	Normalize *process.NormalizeSchemasProcess
+}
+
+// Validate compares the state of the left (a) with that of the right (b),
+// reporting every difference
+func Validate(ctx context.Context, a source.Source, b source.Source, options ValidateOptions) (*validation.Report, error) {
+	stateA, err := a.GetState(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read left state: %w", err)
+	}
+	stateB, err := b.GetState(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read right state: %w", err)
+	}
+
+	if options.IDMapping != nil {
+		stateA = stateA.MapIDs(func(id state.ContextID) int {
+			if newID, ok := options.IDMapping[id]; ok {
+				return newID
+			}
+			return id.ID
+		})
+	}
+
+	if options.Normalize != nil {
+		for _, s := range []*state.State{stateA, stateB} {
+			_, err = options.Normalize.Process(ctx, s)
+			if err != nil {
+				return nil, fmt.Errorf("unable to normalize schemas: %w", err)
+			}
+		}
+	}
+
+	report := validation.NewReport()
+	err = stateA.Sort()
+	if err != nil {
+		report.Errorf("reference_cycle", "", 0, "left: %v", err)
+	}
+	err = stateB.Sort()
+	if err != nil {
+		report.Errorf("reference_cycle", "", 0, "right: %v", err)
+	}
+	report.Merge(stateA.Validate())
+	report.Merge(stateB.Validate())
+	report.Merge(diff.Diff(stateA, stateB))
+	return report, nil
+}
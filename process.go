@@ -1,5 +0,0 @@
-package main
-
-type Process interface {
-	Process(*State) (*State, error)
-}
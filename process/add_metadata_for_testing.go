@@ -1,18 +1,22 @@
-package main
+package process
 
-import "github.com/twmb/franz-go/pkg/sr"
+import (
+	"context"
+	"github.com/twmb/franz-go/pkg/sr"
+	"go-schema-migrator/state"
+)
 
 type AddMetadataProcess struct{}
 
-func (f AddMetadataProcess) Process(state *State) (*State, error) {
+func (f AddMetadataProcess) Process(ctx context.Context, s *state.State) (*state.State, error) {
 
-	for i := range state.SubjectSchemas {
+	for i := range s.SubjectSchemas {
 		metadata := buildMetadata()
-		state.SubjectSchemas[i].SchemaMetadata = metadata
+		s.SubjectSchemas[i].SchemaMetadata = metadata
 		//schema.Schema.SchemaMetadata = metadata
 		_ = "foo"
 	}
-	return state, nil
+	return s, nil
 }
 
 func buildMetadata() *sr.SchemaMetadata {
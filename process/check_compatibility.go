@@ -0,0 +1,115 @@
+package process
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"github.com/twmb/franz-go/pkg/sr"
+	"go-schema-migrator/internal/collections"
+	"go-schema-migrator/state"
+	"go-schema-migrator/validation"
+	"maps"
+	"slices"
+)
+
+// CheckCompatibilityProcess checks each version of a subject against the
+// earlier versions, under the subject's compatibility level or else Level
+type CheckCompatibilityProcess struct {
+	Level string `koanf:"level"`
+}
+
+// compatibilityLevel returns the level that applies to a subject: its own, or
+// that of its context, or else the global level. Registries default to BACKWARD.
+func compatibilityLevel(s *state.State, subject string) sr.CompatibilityLevel {
+	levels := make(map[string]sr.CompatibilityLevel)
+	for _, result := range s.CompatibilityResults {
+		levels[result.Subject] = result.Level
+	}
+	if level := levels[subject]; level != 0 {
+		return level
+	}
+	if level := levels[state.Qualify(state.SubjectContext(subject), "")]; level != 0 {
+		return level
+	}
+	if s.GlobalCompatibility != nil && s.GlobalCompatibility.Level != 0 {
+		return s.GlobalCompatibility.Level
+	}
+	return sr.CompatBackward
+}
+
+func (f CheckCompatibilityProcess) Process(ctx context.Context, s *state.State) (*state.State, error) {
+	var override sr.CompatibilityLevel
+	if f.Level != "" {
+		err := override.UnmarshalText([]byte(f.Level))
+		if err != nil {
+			return nil, fmt.Errorf("unable to unmarshall compatibility level: %w", err)
+		}
+	}
+
+	// Soft deleted versions aren't checked against, as in the registry
+	softDeletions := collections.ToMap(s.SoftDeletions)
+	versions := make(map[string][]sr.SubjectSchema)
+	for _, subjectSchema := range s.SubjectSchemas {
+		if !softDeletions[state.GetReference(subjectSchema)] {
+			versions[subjectSchema.Subject] = append(versions[subjectSchema.Subject], subjectSchema)
+		}
+	}
+
+	parser := newSchemaParser(s)
+	report := validation.NewReport()
+	for _, subject := range slices.Sorted(maps.Keys(versions)) {
+		level := override
+		if level == 0 {
+			level = compatibilityLevel(s, subject)
+		}
+		backward := level == sr.CompatBackward || level == sr.CompatBackwardTransitive || level == sr.CompatFull || level == sr.CompatFullTransitive
+		forward := level == sr.CompatForward || level == sr.CompatForwardTransitive || level == sr.CompatFull || level == sr.CompatFullTransitive
+		transitive := level == sr.CompatBackwardTransitive || level == sr.CompatForwardTransitive || level == sr.CompatFullTransitive
+		if !backward && !forward {
+			continue
+		}
+
+		subjectSchemas := slices.SortedFunc(slices.Values(versions[subject]), func(a, b sr.SubjectSchema) int {
+			return cmp.Compare(a.Version, b.Version)
+		})
+		parsed := make([]any, len(subjectSchemas))
+		for i, subjectSchema := range subjectSchemas {
+			schema, err := parser.parseForCompatibility(subjectSchema)
+			if err != nil {
+				report.Warnf("unchecked_compatibility", subject, subjectSchema.Version, "unable to parse %v schema, so it wasn't checked: %v", subjectSchema.Type, err)
+				continue
+			}
+			parsed[i] = schema
+		}
+
+		for i, subjectSchema := range subjectSchemas {
+			if parsed[i] == nil {
+				continue
+			}
+			// Each version is checked against the latest version before it, or
+			// against every earlier version if the level is transitive
+			for j := i - 1; j >= 0; j-- {
+				previous := subjectSchemas[j]
+				switch {
+				case previous.Type != subjectSchema.Type:
+					report.Errorf("incompatible_schema", subject, subjectSchema.Version, "under %v, type changed from %v in version %v to %v", level, previous.Type, previous.Version, subjectSchema.Type)
+				case parsed[j] != nil:
+					if backward {
+						for _, problem := range checkCompatible(subjectSchema.Type, parsed[i], parsed[j]) {
+							report.Errorf("incompatible_schema", subject, subjectSchema.Version, "under %v, can't read data written with version %v: %v", level, previous.Version, problem)
+						}
+					}
+					if forward {
+						for _, problem := range checkCompatible(subjectSchema.Type, parsed[j], parsed[i]) {
+							report.Errorf("incompatible_schema", subject, subjectSchema.Version, "under %v, data written with it can't be read with version %v: %v", level, previous.Version, problem)
+						}
+					}
+				}
+				if !transitive {
+					break
+				}
+			}
+		}
+	}
+	return s, report.Err()
+}
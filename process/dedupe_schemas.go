@@ -0,0 +1,134 @@
+package process
+
+import (
+	"context"
+	"fmt"
+	"github.com/twmb/franz-go/pkg/sr"
+	"go-schema-migrator/internal/collections"
+	"go-schema-migrator/state"
+	"go-schema-migrator/validation"
+	"maps"
+	"slices"
+)
+
+// DedupeSchemasProcess finds schemas that are registered under more than one
+// ID in the same context, matching either their exact text or, with Match set
+// to normalized, their canonical form. With Collapse set, every duplicate is
+// given the lowest of its IDs, the IDs no longer used are reserved, and the
+// mapping is written to the Output file if one is given.
+type DedupeSchemasProcess struct {
+	Match     string                  `koanf:"match"`
+	Normalize NormalizeSchemasProcess `koanf:"normalize"`
+	Collapse  bool                    `koanf:"collapse"`
+	Output    string                  `koanf:"output"`
+
+	report *validation.Report
+}
+
+// Report returns the duplicates that were found
+func (f *DedupeSchemasProcess) Report() *validation.Report {
+	return f.report
+}
+
+// identities returns what each schema is matched on, by its position in the state
+func (f *DedupeSchemasProcess) identities(s *state.State) ([]string, error) {
+	identities := make([]string, len(s.SubjectSchemas))
+	switch f.Match {
+	case "", "exact":
+		for i, subjectSchema := range s.SubjectSchemas {
+			identities[i] = schemaIdentity(subjectSchema.Schema)
+		}
+		return identities, nil
+	case "normalized":
+		problems := validation.NewReport()
+		normalizer := f.Normalize.normalizer(s)
+		for i, subjectSchema := range s.SubjectSchemas {
+			normalized, err := normalizer.normalize(subjectSchema)
+			if err != nil {
+				problems.Errorf("invalid_schema", subjectSchema.Subject, subjectSchema.Version, "unable to normalize %v schema: %v", subjectSchema.Type, err)
+				continue
+			}
+			schema := subjectSchema.Schema
+			schema.Schema = normalized
+			identities[i] = schemaIdentity(schema)
+		}
+		return identities, problems.Err()
+	}
+	return nil, fmt.Errorf("unknown match: %v", f.Match)
+}
+
+func (f *DedupeSchemasProcess) Process(ctx context.Context, s *state.State) (*state.State, error) {
+	f.report = validation.NewReport()
+	identities, err := f.identities(s)
+	if err != nil {
+		return s, err
+	}
+
+	// Schema IDs are only unique within a context, so duplicates are too
+	type contextSchema struct {
+		context  string
+		identity string
+	}
+	lowest := make(map[contextSchema]sr.SubjectSchema)
+	for i, subjectSchema := range s.SubjectSchemas {
+		key := contextSchema{context: state.SubjectContext(subjectSchema.Subject), identity: identities[i]}
+		if kept, ok := lowest[key]; !ok || subjectSchema.ID < kept.ID {
+			lowest[key] = subjectSchema
+		}
+	}
+
+	// Duplicates take the ID of the schema that is kept, and its text, which
+	// may be formatted differently
+	mapping := make(map[state.ContextID]sr.SubjectSchema)
+	for i, subjectSchema := range s.SubjectSchemas {
+		kept := lowest[contextSchema{context: state.SubjectContext(subjectSchema.Subject), identity: identities[i]}]
+		id := kept.ID
+		if id == subjectSchema.ID {
+			continue
+		}
+		mapping[state.GetContextID(subjectSchema)] = kept
+		if f.Collapse {
+			f.report.Warnf("duplicate_schema", subjectSchema.Subject, subjectSchema.Version, "schema ID %v duplicates schema ID %v, and was collapsed onto it", subjectSchema.ID, id)
+		} else {
+			f.report.Warnf("duplicate_schema", subjectSchema.Subject, subjectSchema.Version, "schema ID %v duplicates schema ID %v", subjectSchema.ID, id)
+		}
+	}
+	if !f.Collapse || len(mapping) == 0 {
+		return s, nil
+	}
+
+	// An ID that is still used in another context stays in use
+	used := make(map[int]bool)
+	for _, subjectSchema := range s.SubjectSchemas {
+		if _, ok := mapping[state.GetContextID(subjectSchema)]; !ok {
+			used[subjectSchema.ID] = true
+		}
+	}
+	output := make(map[state.ContextID]int)
+	reserved := collections.ToMap(s.ReservedIDs)
+	for duplicate, kept := range mapping {
+		output[duplicate] = kept.ID
+		if !used[duplicate.ID] {
+			reserved[duplicate.ID] = true
+		}
+	}
+
+	if f.Output != "" {
+		err = writeIDMapping(f.Output, output)
+		if err != nil {
+			return nil, fmt.Errorf("unable to dedupe schemas: %w", err)
+		}
+	}
+
+	result := *s
+	result.SubjectSchemas = make([]sr.SubjectSchema, 0, len(s.SubjectSchemas))
+	for _, subjectSchema := range s.SubjectSchemas {
+		if kept, ok := mapping[state.GetContextID(subjectSchema)]; ok {
+			subjectSchema.ID = kept.ID
+			subjectSchema.Schema.Schema = kept.Schema.Schema
+		}
+		result.SubjectSchemas = append(result.SubjectSchemas, subjectSchema)
+	}
+	result.ReservedIDs = slices.Sorted(maps.Keys(reserved))
+	return &result, nil
+}
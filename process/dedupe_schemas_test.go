@@ -0,0 +1,88 @@
+package process
+
+import (
+	"context"
+	"maps"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/twmb/franz-go/pkg/sr"
+	"go-schema-migrator/state"
+)
+
+func TestDedupeSchemasCollapse(t *testing.T) {
+	metadata := &sr.SchemaMetadata{Properties: map[string]string{"owner": "team"}}
+	tests := []struct {
+		name     string
+		schemas  []sr.SubjectSchema
+		want     []int
+		reserved []int
+		mapping  map[state.ContextID]int
+	}{
+		{
+			name: "duplicates collapse onto the lowest ID",
+			schemas: []sr.SubjectSchema{
+				{Subject: "a", Version: 1, ID: 1, Schema: sr.Schema{Schema: `"string"`}},
+				{Subject: "b", Version: 1, ID: 2, Schema: sr.Schema{Schema: `"string"`}},
+				{Subject: "c", Version: 1, ID: 3, Schema: sr.Schema{Schema: `"int"`}},
+			},
+			want:     []int{1, 1, 3},
+			reserved: []int{2},
+			mapping:  map[state.ContextID]int{{Context: ".", ID: 2}: 1},
+		},
+		{
+			name: "schemas with different metadata aren't duplicates",
+			schemas: []sr.SubjectSchema{
+				{Subject: "a", Version: 1, ID: 1, Schema: sr.Schema{Schema: `"string"`}},
+				{Subject: "b", Version: 1, ID: 2, Schema: sr.Schema{Schema: `"string"`, SchemaMetadata: metadata}},
+				{Subject: "c", Version: 1, ID: 3, Schema: sr.Schema{Schema: `"string"`, SchemaMetadata: metadata}},
+			},
+			want:     []int{1, 2, 2},
+			reserved: []int{3},
+			mapping:  map[state.ContextID]int{{Context: ".", ID: 3}: 2},
+		},
+		{
+			name: "duplicates are only found within a context",
+			schemas: []sr.SubjectSchema{
+				{Subject: "a", Version: 1, ID: 1, Schema: sr.Schema{Schema: `"string"`}},
+				{Subject: ":.x:a", Version: 1, ID: 2, Schema: sr.Schema{Schema: `"string"`}},
+				{Subject: ":.x:b", Version: 1, ID: 3, Schema: sr.Schema{Schema: `"string"`}},
+			},
+			want:     []int{1, 2, 2},
+			reserved: []int{3},
+			mapping:  map[state.ContextID]int{{Context: ".x", ID: 3}: 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output := filepath.Join(t.TempDir(), "ids.yaml")
+			p := &DedupeSchemasProcess{Collapse: true, Output: output}
+			s, err := p.Process(context.Background(), &state.State{SubjectSchemas: tt.schemas})
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := make([]int, 0, len(s.SubjectSchemas))
+			for _, subjectSchema := range s.SubjectSchemas {
+				got = append(got, subjectSchema.ID)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got IDs %v, want %v", got, tt.want)
+			}
+			if !slices.Equal(s.ReservedIDs, tt.reserved) {
+				t.Errorf("got reserved IDs %v, want %v", s.ReservedIDs, tt.reserved)
+			}
+			mapping, err := ReadIDMapping(output)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !maps.Equal(mapping, tt.mapping) {
+				t.Errorf("got mapping %v, want %v", mapping, tt.mapping)
+			}
+			if problems := s.Validate().Problems; len(problems) != 0 {
+				t.Errorf("got %v, want a valid state", problems)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package process
+
+import (
+	"context"
+	"fmt"
+	"github.com/twmb/franz-go/pkg/sr"
+	"go-schema-migrator/internal/collections"
+	"go-schema-migrator/state"
+	"go-schema-migrator/validation"
+	"maps"
+	"slices"
+)
+
+// DropSoftDeletedProcess removes soft deleted subject versions from the state.
+// Referenced decides what happens to a soft deleted version that a live schema
+// still references: "fail" (the default) reports it, and "keep" leaves it in
+// place, still soft deleted.
+type DropSoftDeletedProcess struct {
+	Referenced string `koanf:"referenced"`
+}
+
+func (p DropSoftDeletedProcess) Process(ctx context.Context, s *state.State) (*state.State, error) {
+	referencedOption := p.Referenced
+	if referencedOption == "" {
+		referencedOption = "fail"
+	}
+	if referencedOption != "fail" && referencedOption != "keep" {
+		return nil, fmt.Errorf("unable to drop soft deleted versions: unknown referenced option: %v", referencedOption)
+	}
+
+	softDeletions := collections.ToMap(s.SoftDeletions)
+	live := collections.Filter(s.SubjectSchemas, func(subjectSchema sr.SubjectSchema) bool {
+		return !softDeletions[state.GetReference(subjectSchema)]
+	})
+
+	report := validation.NewReport()
+	if referencedOption == "fail" {
+		for _, subjectSchema := range live {
+			for _, schemaReference := range subjectSchema.References {
+				reference := state.ResolveReference(subjectSchema, schemaReference)
+				if softDeletions[reference] {
+					report.Errorf("referenced_soft_deletion", subjectSchema.Subject, subjectSchema.Version, "references subject %v version %v, which is soft deleted", reference.Subject, reference.Version)
+				}
+			}
+		}
+	}
+	if err := report.Err(); err != nil {
+		return s, err
+	}
+
+	// Soft deleted versions that live schemas depend on, directly or not, are kept
+	referenced := s.ReferencedBy(live)
+	dropped := func(reference sr.SubjectVersion) bool {
+		return softDeletions[reference] && !referenced[reference]
+	}
+
+	result := *s
+	result.SubjectSchemas = collections.Filter(s.SubjectSchemas, func(subjectSchema sr.SubjectSchema) bool {
+		return !dropped(state.GetReference(subjectSchema))
+	})
+	result.SoftDeletions = collections.Filter(s.SoftDeletions, func(deletion sr.SubjectVersion) bool {
+		return !dropped(deletion)
+	})
+
+	remaining := make(map[string]bool)
+	for _, subjectSchema := range result.SubjectSchemas {
+		remaining[subjectSchema.Subject] = true
+	}
+	result.DeletedSubjects = collections.Filter(s.DeletedSubjects, func(subject string) bool {
+		return remaining[subject]
+	})
+
+	// Consumers may still hold the IDs of dropped versions, so they are
+	// reserved unless a remaining schema still uses them
+	inUse := make(map[int]bool)
+	for _, subjectSchema := range result.SubjectSchemas {
+		inUse[subjectSchema.ID] = true
+	}
+	reserved := collections.ToMap(s.ReservedIDs)
+	for _, subjectSchema := range s.SubjectSchemas {
+		if dropped(state.GetReference(subjectSchema)) && !inUse[subjectSchema.ID] {
+			reserved[subjectSchema.ID] = true
+		}
+	}
+	result.ReservedIDs = slices.Sorted(maps.Keys(reserved))
+
+	return &result, nil
+}
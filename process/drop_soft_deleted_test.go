@@ -0,0 +1,74 @@
+package process
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/twmb/franz-go/pkg/sr"
+	"go-schema-migrator/state"
+)
+
+func TestDropSoftDeleted(t *testing.T) {
+	tests := []struct {
+		name       string
+		referenced string
+		state      *state.State
+		want       *state.State
+		errors     []string
+	}{
+		{
+			name: "soft deleted versions and subjects are dropped, and their IDs reserved",
+			state: &state.State{
+				SubjectSchemas:       []sr.SubjectSchema{schema("a", 1, 1), schema("a", 2, 2), schema("b", 1, 3), schema("c", 1, 1)},
+				SoftDeletions:        []sr.SubjectVersion{{Subject: "a", Version: 1}, {Subject: "b", Version: 1}},
+				DeletedSubjects:      []string{"b"},
+				CompatibilityResults: []sr.CompatibilityResult{{Subject: "a", Level: sr.CompatFull}},
+			},
+			want: &state.State{
+				SubjectSchemas:       []sr.SubjectSchema{schema("a", 2, 2), schema("c", 1, 1)},
+				CompatibilityResults: []sr.CompatibilityResult{{Subject: "a", Level: sr.CompatFull}},
+				ReservedIDs:          []int{3},
+			},
+		},
+		{
+			name: "a live reference to a soft deleted version is reported",
+			state: &state.State{
+				SubjectSchemas: []sr.SubjectSchema{schema("a", 1, 1), schema("b", 1, 2, reference("a", 1))},
+				SoftDeletions:  []sr.SubjectVersion{{Subject: "a", Version: 1}},
+			},
+			errors: []string{"referenced_soft_deletion b/1"},
+		},
+		{
+			name:       "a referenced soft deleted version is kept, with what it references",
+			referenced: "keep",
+			state: &state.State{
+				SubjectSchemas:  []sr.SubjectSchema{schema("a", 1, 1), schema("a", 2, 2, reference("a", 1)), schema("b", 1, 3, reference("a", 2)), schema("a", 3, 4)},
+				SoftDeletions:   []sr.SubjectVersion{{Subject: "a", Version: 1}, {Subject: "a", Version: 2}, {Subject: "a", Version: 3}},
+				DeletedSubjects: []string{"a"},
+			},
+			want: &state.State{
+				SubjectSchemas:  []sr.SubjectSchema{schema("a", 1, 1), schema("a", 2, 2, reference("a", 1)), schema("b", 1, 3, reference("a", 2))},
+				SoftDeletions:   []sr.SubjectVersion{{Subject: "a", Version: 1}, {Subject: "a", Version: 2}},
+				DeletedSubjects: []string{"a"},
+				ReservedIDs:     []int{4},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DropSoftDeletedProcess{Referenced: tt.referenced}.Process(context.Background(), tt.state)
+			if tt.errors != nil {
+				if got := checks(t, err); !slices.Equal(got, tt.errors) {
+					t.Errorf("got %v, want %v", got, tt.errors)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkState(t, got, tt.want)
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package process
+
+import (
+	"context"
+	"fmt"
+	"github.com/twmb/franz-go/pkg/sr"
+	"go-schema-migrator/internal/collections"
+	"go-schema-migrator/state"
+	"go-schema-migrator/validation"
+)
+
+// FilterSubjectsProcess keeps only the subjects selected by its include and
+// exclude rules. Dependencies decides what happens when a kept schema
+// references a subject version that was filtered out: "fail" (the default)
+// reports it, and "include" keeps that subject version after all.
+type FilterSubjectsProcess struct {
+	Include      []SubjectMatcher `koanf:"include"`
+	Exclude      []SubjectMatcher `koanf:"exclude"`
+	Dependencies string           `koanf:"dependencies"`
+}
+
+func (f FilterSubjectsProcess) Process(ctx context.Context, s *state.State) (*state.State, error) {
+	subjectFilter, err := newSubjectFilter(f.Include, f.Exclude)
+	if err != nil {
+		return nil, fmt.Errorf("unable to filter subjects: %w", err)
+	}
+	dependencies := f.Dependencies
+	if dependencies == "" {
+		dependencies = "fail"
+	}
+	if dependencies != "fail" && dependencies != "include" {
+		return nil, fmt.Errorf("unable to filter subjects: unknown dependencies option: %v", dependencies)
+	}
+
+	index := make(map[sr.SubjectVersion]sr.SubjectSchema)
+	kept := make(map[sr.SubjectVersion]bool)
+	pending := make([]sr.SubjectSchema, 0)
+	for _, subjectSchema := range s.SubjectSchemas {
+		index[state.GetReference(subjectSchema)] = subjectSchema
+		if subjectFilter.matches(subjectSchema.Subject) {
+			kept[state.GetReference(subjectSchema)] = true
+			pending = append(pending, subjectSchema)
+		}
+	}
+
+	// Follow references from the kept schemas, through any dependencies that
+	// are brought back, until every reference has been checked. References
+	// that can't be resolved at all are left for validate to report.
+	report := validation.NewReport()
+	for len(pending) > 0 {
+		subjectSchema := pending[0]
+		pending = pending[1:]
+		for _, schemaReference := range subjectSchema.References {
+			reference := state.ResolveReference(subjectSchema, schemaReference)
+			dependency, ok := index[reference]
+			if !ok || kept[reference] {
+				continue
+			}
+			if dependencies == "fail" {
+				report.Errorf("filtered_dependency", subjectSchema.Subject, subjectSchema.Version, "references subject %v version %v, which is filtered out", reference.Subject, reference.Version)
+				continue
+			}
+			kept[reference] = true
+			pending = append(pending, dependency)
+		}
+	}
+	if err := report.Err(); err != nil {
+		return s, err
+	}
+
+	subjects := make(map[string]bool)
+	for _, subject := range s.Subjects() {
+		subjects[subject] = subjectFilter.matches(subject)
+	}
+	for reference := range kept {
+		subjects[reference.Subject] = true
+	}
+
+	result := *s
+	result.SubjectSchemas = collections.Filter(s.SubjectSchemas, func(subjectSchema sr.SubjectSchema) bool {
+		return kept[state.GetReference(subjectSchema)]
+	})
+	result.SoftDeletions = collections.Filter(s.SoftDeletions, func(deletion sr.SubjectVersion) bool {
+		_, known := index[deletion]
+		return kept[deletion] || (!known && subjectFilter.matches(deletion.Subject))
+	})
+	result.DeletedSubjects = collections.Filter(s.DeletedSubjects, func(subject string) bool {
+		return subjects[subject]
+	})
+	result.HardDeletions = collections.Filter(s.HardDeletions, func(deletion state.HardDeletion) bool {
+		return subjectFilter.matches(deletion.Subject)
+	})
+	result.CompatibilityResults = collections.Filter(s.CompatibilityResults, func(compatibilityResult sr.CompatibilityResult) bool {
+		return subjects[compatibilityResult.Subject]
+	})
+	result.Modes = collections.Filter(s.Modes, func(modeResult sr.ModeResult) bool {
+		return subjects[modeResult.Subject]
+	})
+
+	return &result, nil
+}
@@ -0,0 +1,84 @@
+package process
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/twmb/franz-go/pkg/sr"
+	"go-schema-migrator/state"
+)
+
+func TestFilterSubjects(t *testing.T) {
+	tests := []struct {
+		name    string
+		process FilterSubjectsProcess
+		state   *state.State
+		want    *state.State
+		errors  []string
+	}{
+		{
+			name:    "excluded subjects are removed along with their deletions and configuration",
+			process: FilterSubjectsProcess{Exclude: []SubjectMatcher{{Glob: "b*"}}},
+			state: &state.State{
+				SubjectSchemas:       []sr.SubjectSchema{schema("a", 1, 1), schema("b", 1, 2), schema("b", 3, 3)},
+				SoftDeletions:        []sr.SubjectVersion{{Subject: "b", Version: 1}, {Subject: "b", Version: 3}},
+				DeletedSubjects:      []string{"b"},
+				HardDeletions:        []state.HardDeletion{{Subject: "a", Version: 2, ID: 4}, {Subject: "b", Version: 2, ID: 5}},
+				CompatibilityResults: []sr.CompatibilityResult{{Subject: "a", Level: sr.CompatFull}, {Subject: "b", Level: sr.CompatNone}},
+				Modes:                []sr.ModeResult{{Subject: "b", Mode: sr.ModeReadOnly}},
+			},
+			want: &state.State{
+				SubjectSchemas:       []sr.SubjectSchema{schema("a", 1, 1)},
+				HardDeletions:        []state.HardDeletion{{Subject: "a", Version: 2, ID: 4}},
+				CompatibilityResults: []sr.CompatibilityResult{{Subject: "a", Level: sr.CompatFull}},
+			},
+		},
+		{
+			name:    "filtering out a referenced subject is reported",
+			process: FilterSubjectsProcess{Include: []SubjectMatcher{{Glob: "a"}}},
+			state: &state.State{
+				SubjectSchemas: []sr.SubjectSchema{schema("b", 1, 1), schema("a", 1, 2, reference("b", 1))},
+			},
+			errors: []string{"filtered_dependency a/1"},
+		},
+		{
+			name:    "a referenced subject version is kept with its dependents",
+			process: FilterSubjectsProcess{Include: []SubjectMatcher{{Glob: "a"}}, Dependencies: "include"},
+			state: &state.State{
+				SubjectSchemas:       []sr.SubjectSchema{schema("c", 1, 1), schema("b", 1, 2, reference("c", 1)), schema("b", 2, 3), schema("a", 1, 4, reference("b", 1))},
+				CompatibilityResults: []sr.CompatibilityResult{{Subject: "b", Level: sr.CompatNone}},
+			},
+			want: &state.State{
+				SubjectSchemas:       []sr.SubjectSchema{schema("c", 1, 1), schema("b", 1, 2, reference("c", 1)), schema("a", 1, 4, reference("b", 1))},
+				CompatibilityResults: []sr.CompatibilityResult{{Subject: "b", Level: sr.CompatNone}},
+			},
+		},
+		{
+			name:    "subjects are matched within their context",
+			process: FilterSubjectsProcess{Include: []SubjectMatcher{{Regex: "^:\\.orders:"}}},
+			state: &state.State{
+				SubjectSchemas: []sr.SubjectSchema{schema("a", 1, 1), schema(":.orders:a", 1, 1)},
+			},
+			want: &state.State{
+				SubjectSchemas: []sr.SubjectSchema{schema(":.orders:a", 1, 1)},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.process.Process(context.Background(), tt.state)
+			if tt.errors != nil {
+				if got := checks(t, err); !slices.Equal(got, tt.errors) {
+					t.Errorf("got %v, want %v", got, tt.errors)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkState(t, got, tt.want)
+		})
+	}
+}
@@ -0,0 +1,328 @@
+package process
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/desc/protoprint"
+	"github.com/twmb/franz-go/pkg/sr"
+	"go-schema-migrator/state"
+	"go-schema-migrator/validation"
+	"slices"
+	"strings"
+)
+
+var (
+	avroPrimitives = []string{"null", "boolean", "int", "long", "float", "double", "bytes", "string"}
+	// avroCanonicalAttributes are the attributes kept by Parsing Canonical Form, in order
+	avroCanonicalAttributes = []string{"name", "type", "fields", "symbols", "items", "values", "size"}
+)
+
+// NormalizeSchemasProcess rewrites every schema into a canonical form, so that
+// schemas that differ only in formatting compare as equal. Avro schemas are
+// written in Parsing Canonical Form, optionally keeping docs, defaults and
+// logical types.
+type NormalizeSchemasProcess struct {
+	KeepDocs         bool `koanf:"keep_docs"`
+	KeepDefaults     bool `koanf:"keep_defaults"`
+	KeepLogicalTypes bool `koanf:"keep_logical_types"`
+
+	report *validation.Report
+}
+
+// schemaNormalizer rewrites schemas into a canonical form
+type schemaNormalizer struct {
+	parser schemaParser
+	// keep are the Avro attributes kept beyond those of Parsing Canonical Form
+	keep []string
+}
+
+func (f *NormalizeSchemasProcess) normalizer(s *state.State) schemaNormalizer {
+	keep := make([]string, 0)
+	if f.KeepDocs {
+		keep = append(keep, "doc")
+	}
+	if f.KeepDefaults {
+		keep = append(keep, "default")
+	}
+	if f.KeepLogicalTypes {
+		keep = append(keep, "logicalType", "precision", "scale")
+	}
+	return schemaNormalizer{parser: newSchemaParser(s), keep: keep}
+}
+
+// marshalCompact writes a value as JSON without whitespace or HTML escaping.
+// Object keys are sorted.
+func marshalCompact(value any) ([]byte, error) {
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(value); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buffer.Bytes(), []byte("\n")), nil
+}
+
+func decodeJSON(text string) (any, error) {
+	decoder := json.NewDecoder(strings.NewReader(text))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	if decoder.More() {
+		return nil, fmt.Errorf("unexpected data after the schema")
+	}
+	return value, nil
+}
+
+// avroFullname returns the full name of a named type, given the namespace it
+// is defined or used in
+func avroFullname(name string, namespace string) string {
+	if strings.Contains(name, ".") || namespace == "" {
+		return name
+	}
+	return namespace + "." + name
+}
+
+// avroNamespace returns the namespace part of a full name
+func avroNamespace(fullname string) string {
+	if i := strings.LastIndex(fullname, "."); i >= 0 {
+		return fullname[:i]
+	}
+	return ""
+}
+
+// writeAvro writes an Avro schema in Parsing Canonical Form, keeping the
+// normalizer's extra attributes after the canonical ones
+func (n schemaNormalizer) writeAvro(buffer *bytes.Buffer, schema any, namespace string) error {
+	switch value := schema.(type) {
+	case string:
+		if !slices.Contains(avroPrimitives, value) {
+			value = avroFullname(value, namespace)
+		}
+		return n.writeJSON(buffer, value)
+	case []any:
+		buffer.WriteByte('[')
+		for i, branch := range value {
+			if i > 0 {
+				buffer.WriteByte(',')
+			}
+			if err := n.writeAvro(buffer, branch, namespace); err != nil {
+				return err
+			}
+		}
+		buffer.WriteByte(']')
+		return nil
+	case map[string]any:
+		return n.writeAvroObject(buffer, value, namespace)
+	}
+	return fmt.Errorf("unexpected schema %v", schema)
+}
+
+func (n schemaNormalizer) writeAvroObject(buffer *bytes.Buffer, schema map[string]any, namespace string) error {
+	schemaType, ok := schema["type"].(string)
+	if !ok {
+		// A type given as a schema rather than a name
+		return n.writeAvro(buffer, schema["type"], namespace)
+	}
+	switch schemaType {
+	case "record", "error", "enum", "fixed", "array", "map":
+	default:
+		if !slices.Contains(avroPrimitives, schemaType) {
+			// A named type given as an object
+			return n.writeAvro(buffer, schemaType, namespace)
+		}
+	}
+
+	attributes := make(map[string]any)
+	for _, attribute := range n.keep {
+		if value, ok := schema[attribute]; ok {
+			attributes[attribute] = value
+		}
+	}
+	if slices.Contains(avroPrimitives, schemaType) && len(attributes) == 0 {
+		return n.writeJSON(buffer, schemaType)
+	}
+	attributes["type"] = schemaType
+
+	switch schemaType {
+	case "record", "error", "enum", "fixed":
+		name, _ := schema["name"].(string)
+		if explicit, ok := schema["namespace"].(string); ok {
+			namespace = explicit
+		}
+		name = avroFullname(name, namespace)
+		namespace = avroNamespace(name)
+		attributes["name"] = name
+	}
+	switch schemaType {
+	case "record", "error":
+		fields := make([]any, 0)
+		schemaFields, _ := schema["fields"].([]any)
+		for _, schemaField := range schemaFields {
+			field, ok := schemaField.(map[string]any)
+			if !ok {
+				return fmt.Errorf("unexpected field %v", schemaField)
+			}
+			var fieldType bytes.Buffer
+			if err := n.writeAvro(&fieldType, field["type"], namespace); err != nil {
+				return err
+			}
+			canonicalField := map[string]any{"name": field["name"], "type": json.RawMessage(fieldType.Bytes())}
+			for _, attribute := range n.keep {
+				if value, ok := field[attribute]; ok {
+					canonicalField[attribute] = value
+				}
+			}
+			fields = append(fields, canonicalField)
+		}
+		attributes["fields"] = fields
+	case "enum":
+		attributes["symbols"] = schema["symbols"]
+	case "fixed":
+		attributes["size"] = schema["size"]
+	case "array":
+		var items bytes.Buffer
+		if err := n.writeAvro(&items, schema["items"], namespace); err != nil {
+			return err
+		}
+		attributes["items"] = json.RawMessage(items.Bytes())
+	case "map":
+		var values bytes.Buffer
+		if err := n.writeAvro(&values, schema["values"], namespace); err != nil {
+			return err
+		}
+		attributes["values"] = json.RawMessage(values.Bytes())
+	}
+	return n.writeOrdered(buffer, attributes)
+}
+
+// writeOrdered writes an object with the canonical attributes first, in their
+// canonical order, followed by any kept attributes
+func (n schemaNormalizer) writeOrdered(buffer *bytes.Buffer, attributes map[string]any) error {
+	buffer.WriteByte('{')
+	written := 0
+	for _, attribute := range append(slices.Clone(avroCanonicalAttributes), n.keep...) {
+		value, ok := attributes[attribute]
+		if !ok {
+			continue
+		}
+		if written > 0 {
+			buffer.WriteByte(',')
+		}
+		written++
+		if err := n.writeJSON(buffer, attribute); err != nil {
+			return err
+		}
+		buffer.WriteByte(':')
+		if fields, ok := value.([]any); ok && attribute == "fields" {
+			buffer.WriteByte('[')
+			for i, field := range fields {
+				if i > 0 {
+					buffer.WriteByte(',')
+				}
+				if err := n.writeOrdered(buffer, field.(map[string]any)); err != nil {
+					return err
+				}
+			}
+			buffer.WriteByte(']')
+			continue
+		}
+		if err := n.writeJSON(buffer, value); err != nil {
+			return err
+		}
+	}
+	buffer.WriteByte('}')
+	return nil
+}
+
+func (n schemaNormalizer) writeJSON(buffer *bytes.Buffer, value any) error {
+	data, err := marshalCompact(value)
+	if err != nil {
+		return err
+	}
+	buffer.Write(data)
+	return nil
+}
+
+func (n schemaNormalizer) normalizeAvro(subjectSchema sr.SubjectSchema) (string, error) {
+	// The schema must be valid, even though its canonical form is written from the JSON
+	if _, err := n.parser.parseAvro(subjectSchema); err != nil {
+		return "", err
+	}
+	schema, err := decodeJSON(subjectSchema.Schema.Schema)
+	if err != nil {
+		return "", err
+	}
+	var buffer bytes.Buffer
+	if err = n.writeAvro(&buffer, schema, ""); err != nil {
+		return "", err
+	}
+	return buffer.String(), nil
+}
+
+func (n schemaNormalizer) normalizeProtobuf(subjectSchema sr.SubjectSchema) (string, error) {
+	file, err := n.parser.parseProtobuf(subjectSchema)
+	if err != nil {
+		return "", err
+	}
+	descriptor, err := desc.WrapFile(file)
+	if err != nil {
+		return "", err
+	}
+	printer := protoprint.Printer{SortElements: true, OmitComments: protoprint.CommentsAll}
+	return printer.PrintProtoToString(descriptor)
+}
+
+func (n schemaNormalizer) normalizeJSON(subjectSchema sr.SubjectSchema) (string, error) {
+	schema, err := decodeJSON(subjectSchema.Schema.Schema)
+	if err != nil {
+		return "", err
+	}
+	data, err := marshalCompact(schema)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// normalize returns the schema text in canonical form
+func (n schemaNormalizer) normalize(subjectSchema sr.SubjectSchema) (string, error) {
+	switch subjectSchema.Type {
+	case sr.TypeAvro:
+		return n.normalizeAvro(subjectSchema)
+	case sr.TypeProtobuf:
+		return n.normalizeProtobuf(subjectSchema)
+	case sr.TypeJSON:
+		return n.normalizeJSON(subjectSchema)
+	}
+	return "", fmt.Errorf("unknown schema type %v", subjectSchema.Type)
+}
+
+// Report returns the schema versions that were rewritten
+func (f *NormalizeSchemasProcess) Report() *validation.Report {
+	return f.report
+}
+
+func (f *NormalizeSchemasProcess) Process(ctx context.Context, s *state.State) (*state.State, error) {
+	f.report = validation.NewReport()
+	problems := validation.NewReport()
+
+	// Schemas are parsed against the state as it was before any were rewritten
+	normalizer := f.normalizer(s)
+	for i, subjectSchema := range s.SubjectSchemas {
+		normalized, err := normalizer.normalize(subjectSchema)
+		if err != nil {
+			problems.Errorf("invalid_schema", subjectSchema.Subject, subjectSchema.Version, "unable to normalize %v schema: %v", subjectSchema.Type, err)
+			continue
+		}
+		if normalized != subjectSchema.Schema.Schema {
+			f.report.Warnf("normalized_schema", subjectSchema.Subject, subjectSchema.Version, "schema was rewritten into canonical form")
+			s.SubjectSchemas[i].Schema.Schema = normalized
+		}
+	}
+	return s, problems.Err()
+}
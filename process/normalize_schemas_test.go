@@ -0,0 +1,211 @@
+package process
+
+import (
+	"context"
+	"testing"
+
+	"github.com/twmb/franz-go/pkg/sr"
+	"go-schema-migrator/state"
+)
+
+func normalizeSchema(t *testing.T, p *NormalizeSchemasProcess, schemaType sr.SchemaType, schema string) string {
+	t.Helper()
+	normalized, err := p.normalizer(&state.State{}).normalize(sr.SubjectSchema{Subject: "test", Version: 1, Schema: sr.Schema{Schema: schema, Type: schemaType}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return normalized
+}
+
+// TestNormalizeAvroCanonicalForm checks schemas against their Parsing
+// Canonical Form, as given by the examples of the Avro specification
+func TestNormalizeAvroCanonicalForm(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+		want   string
+	}{
+		{
+			name:   "primitive",
+			schema: `"null"`,
+			want:   `"null"`,
+		},
+		{
+			name:   "primitive written as an object",
+			schema: `{"type":"long"}`,
+			want:   `"long"`,
+		},
+		{
+			name:   "fixed",
+			schema: `{"type":"fixed","name":"Test","size":1}`,
+			want:   `{"name":"Test","type":"fixed","size":1}`,
+		},
+		{
+			name:   "namespace folded into the name",
+			schema: `{"type":"fixed","name":"MyFixed","namespace":"org.apache.hadoop.avro","size":1}`,
+			want:   `{"name":"org.apache.hadoop.avro.MyFixed","type":"fixed","size":1}`,
+		},
+		{
+			name:   "enum",
+			schema: `{"type":"enum","name":"Test","symbols":["A","B"]}`,
+			want:   `{"name":"Test","type":"enum","symbols":["A","B"]}`,
+		},
+		{
+			name:   "array",
+			schema: `{"type":"array","items":{"type":"enum","name":"Test","symbols":["A","B"]}}`,
+			want:   `{"type":"array","items":{"name":"Test","type":"enum","symbols":["A","B"]}}`,
+		},
+		{
+			name:   "map",
+			schema: `{"values":{"type":"long"},"type":"map"}`,
+			want:   `{"type":"map","values":"long"}`,
+		},
+		{
+			name:   "union",
+			schema: `["string","null",{"type":"long"}]`,
+			want:   `["string","null","long"]`,
+		},
+		{
+			name:   "record",
+			schema: `{"fields":[{"type":"long","name":"f"}],"name":"Test","type":"record"}`,
+			want:   `{"name":"Test","type":"record","fields":[{"name":"f","type":"long"}]}`,
+		},
+		{
+			name:   "error",
+			schema: `{"type":"error","name":"Test","fields":[{"name":"f","type":"long"}]}`,
+			want:   `{"name":"Test","type":"error","fields":[{"name":"f","type":"long"}]}`,
+		},
+		{
+			name:   "recursive record",
+			schema: `{"type":"record","name":"Lisp","fields":[{"name":"value","type":["null","string",{"type":"record","name":"Cons","fields":[{"name":"car","type":"Lisp"},{"name":"cdr","type":"Lisp"}]}]}]}`,
+			want:   `{"name":"Lisp","type":"record","fields":[{"name":"value","type":["null","string",{"name":"Cons","type":"record","fields":[{"name":"car","type":"Lisp"},{"name":"cdr","type":"Lisp"}]}]}]}`,
+		},
+		{
+			name:   "names used in a namespace",
+			schema: `{"type":"record","name":"HandshakeRequest","namespace":"org.apache.avro.ipc","fields":[{"name":"clientHash","type":{"type":"fixed","name":"MD5","size":16}},{"name":"clientProtocol","type":["null","string"]},{"name":"serverHash","type":"MD5"},{"name":"meta","type":["null",{"type":"map","values":"bytes"}]}]}`,
+			want:   `{"name":"org.apache.avro.ipc.HandshakeRequest","type":"record","fields":[{"name":"clientHash","type":{"name":"org.apache.avro.ipc.MD5","type":"fixed","size":16}},{"name":"clientProtocol","type":["null","string"]},{"name":"serverHash","type":"org.apache.avro.ipc.MD5"},{"name":"meta","type":["null",{"type":"map","values":"bytes"}]}]}`,
+		},
+		{
+			name:   "docs, defaults, order and aliases stripped",
+			schema: `{"type":"record","name":"Test","doc":"A test","aliases":["Old"],"fields":[{"name":"f","type":"long","doc":"A field","default":1,"order":"descending"}]}`,
+			want:   `{"name":"Test","type":"record","fields":[{"name":"f","type":"long"}]}`,
+		},
+		{
+			name:   "logical types stripped",
+			schema: `{"type":"record","name":"Test","fields":[{"name":"amount","type":{"type":"bytes","logicalType":"decimal","precision":4,"scale":2}},{"name":"at","type":{"type":"long","logicalType":"timestamp-millis"}}]}`,
+			want:   `{"name":"Test","type":"record","fields":[{"name":"amount","type":"bytes"},{"name":"at","type":"long"}]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := normalizeSchema(t, &NormalizeSchemasProcess{}, sr.TypeAvro, tt.schema); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeAvroKeep(t *testing.T) {
+	tests := []struct {
+		name    string
+		process *NormalizeSchemasProcess
+		schema  string
+		want    string
+	}{
+		{
+			name:    "logical types kept",
+			process: &NormalizeSchemasProcess{KeepLogicalTypes: true},
+			schema:  `{"type":"record","name":"Test","fields":[{"name":"amount","type":{"scale":2,"precision":4,"logicalType":"decimal","type":"bytes"}},{"name":"id","type":"string"}]}`,
+			want:    `{"name":"Test","type":"record","fields":[{"name":"amount","type":{"type":"bytes","logicalType":"decimal","precision":4,"scale":2}},{"name":"id","type":"string"}]}`,
+		},
+		{
+			name:    "docs and defaults kept after the canonical attributes",
+			process: &NormalizeSchemasProcess{KeepDocs: true, KeepDefaults: true},
+			schema:  `{"doc":"A test","type":"record","name":"Test","fields":[{"default":1,"doc":"A field","name":"f","type":"long"}]}`,
+			want:    `{"name":"Test","type":"record","fields":[{"name":"f","type":"long","doc":"A field","default":1}],"doc":"A test"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := normalizeSchema(t, tt.process, sr.TypeAvro, tt.schema); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeJSON(t *testing.T) {
+	schema := `{
+  "type": "object",
+  "properties": {
+    "b": {"type": "string", "pattern": "^<a>&$"},
+    "a": {"type": "number", "maximum": 1.50}
+  },
+  "$id": "https://example.com/test.json"
+}`
+	want := `{"$id":"https://example.com/test.json","properties":{"a":{"maximum":1.50,"type":"number"},"b":{"pattern":"^<a>&$","type":"string"}},"type":"object"}`
+	if got := normalizeSchema(t, &NormalizeSchemasProcess{}, sr.TypeJSON, schema); got != want {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestNormalizeProtobuf(t *testing.T) {
+	schema := `syntax = "proto3";
+package test;
+
+// A message with its fields out of order
+message Order {
+  string id = 2;   // The ID
+  int64 amount = 1;
+  Status status = 3;
+}
+
+enum Status {
+  UNKNOWN = 0;
+  PAID = 1;
+}
+`
+	want := `syntax = "proto3";
+
+package test;
+
+message Order {
+  int64 amount = 1;
+
+  string id = 2;
+
+  Status status = 3;
+}
+
+enum Status {
+  UNKNOWN = 0;
+
+  PAID = 1;
+}
+`
+	if got := normalizeSchema(t, &NormalizeSchemasProcess{}, sr.TypeProtobuf, schema); got != want {
+		t.Errorf("got:\n%v\nwant:\n%v", got, want)
+	}
+}
+
+func TestNormalizeSchemasProcess(t *testing.T) {
+	s := &state.State{SubjectSchemas: []sr.SubjectSchema{
+		{Subject: "a", Version: 1, ID: 1, Schema: sr.Schema{Schema: `{"type": "string"}`}},
+		{Subject: "b", Version: 1, ID: 2, Schema: sr.Schema{Schema: `"string"`}},
+		{Subject: "c", Version: 1, ID: 3, Schema: sr.Schema{Schema: `{"type": "record"}`}},
+	}}
+	p := &NormalizeSchemasProcess{}
+	s, err := p.Process(context.Background(), s)
+	if err == nil {
+		t.Fatal("expected an error for the invalid schema")
+	}
+	if s.SubjectSchemas[0].Schema.Schema != `"string"` {
+		t.Errorf("got %v, want the schema normalized", s.SubjectSchemas[0].Schema.Schema)
+	}
+	problems := p.Report().Problems
+	if len(problems) != 1 || problems[0].Subject != "a" || problems[0].Check != "normalized_schema" {
+		t.Errorf("got %v, want only subject a reported as rewritten", problems)
+	}
+}
@@ -0,0 +1,12 @@
+// Package process transforms and checks a state on its way from a source to
+// a sink
+package process
+
+import (
+	"context"
+	"go-schema-migrator/state"
+)
+
+type Process interface {
+	Process(ctx context.Context, state *state.State) (*state.State, error)
+}
@@ -0,0 +1,55 @@
+package process
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/twmb/franz-go/pkg/sr"
+	"go-schema-migrator/diff"
+	"go-schema-migrator/state"
+	"go-schema-migrator/validation"
+)
+
+// schema returns a subject version whose text is decided by its ID, so that
+// versions sharing an ID are the same schema
+func schema(subject string, version int, id int, references ...sr.SchemaReference) sr.SubjectSchema {
+	text := fmt.Sprintf(`{"type":"fixed","name":"F%v","size":1}`, id)
+	return sr.SubjectSchema{Subject: subject, Version: version, ID: id, Schema: sr.Schema{Schema: text, References: references}}
+}
+
+func reference(subject string, version int) sr.SchemaReference {
+	return sr.SchemaReference{Name: subject, Subject: subject, Version: version}
+}
+
+// checkState fails the test if got differs from want, or isn't a valid state
+func checkState(t *testing.T, got *state.State, want *state.State) {
+	t.Helper()
+	for _, problem := range diff.Diff(want, got).Problems {
+		t.Errorf("%v", problem)
+	}
+	if !slices.Equal(got.HardDeletions, want.HardDeletions) {
+		t.Errorf("got hard deletions %v, want %v", got.HardDeletions, want.HardDeletions)
+	}
+	if !slices.Equal(got.ReservedIDs, want.ReservedIDs) {
+		t.Errorf("got reserved IDs %v, want %v", got.ReservedIDs, want.ReservedIDs)
+	}
+	if report := got.Validate(); report.HasErrors() {
+		t.Errorf("unexpected errors: %v", report.Text())
+	}
+}
+
+// checks returns the check of every problem in the report that err holds
+func checks(t *testing.T, err error) []string {
+	t.Helper()
+	var report *validation.Report
+	if !errors.As(err, &report) {
+		t.Fatalf("got %v, want a report", err)
+	}
+	result := make([]string, 0, len(report.Problems))
+	for _, problem := range report.Problems {
+		result = append(result, fmt.Sprintf("%v %v/%v", problem.Check, problem.Subject, problem.Version))
+	}
+	return result
+}
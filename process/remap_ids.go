@@ -0,0 +1,215 @@
+package process
+
+import (
+	"context"
+	"fmt"
+	"github.com/twmb/franz-go/pkg/sr"
+	"go-schema-migrator/source"
+	"go-schema-migrator/state"
+	"go-schema-migrator/validation"
+	"gopkg.in/yaml.v3"
+	"os"
+	"strconv"
+)
+
+// RemapIDsProcess gives schemas new IDs, so that registries whose IDs overlap
+// can be merged into one target. The strategy is one of:
+//   - offset: add Offset to every ID
+//   - mapping: look each ID up in the Mapping file
+//   - next_free: number schemas from the highest ID of their context in the
+//     Target registry, reusing the target's ID for any schema it already has
+//
+// IDs are mapped within their context, and two IDs can only be given the same
+// new ID if they are the same schema. The IDs used are written to the Output
+// file, if one is given.
+type RemapIDsProcess struct {
+	Strategy string             `koanf:"strategy"`
+	Offset   int                `koanf:"offset"`
+	Mapping  string             `koanf:"mapping"`
+	Target   *source.RestSource `koanf:"target"`
+	Output   string             `koanf:"output"`
+}
+
+// ReadIDMapping reads a YAML map of old schema IDs to new schema IDs. IDs in
+// the default context are plain numbers, and IDs in any other context are
+// qualified like subjects, such as ":.orders:12".
+func ReadIDMapping(filename string) (map[state.ContextID]int, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read %v: %w", filename, err)
+	}
+	values := make(map[string]int)
+	err = yaml.Unmarshal(data, &values)
+	if err != nil {
+		return nil, fmt.Errorf("unable to unmarshall yaml from %v: %w", filename, err)
+	}
+	mapping := make(map[state.ContextID]int)
+	for key, newID := range values {
+		idContext, name := state.SplitContext(key)
+		id, err := strconv.Atoi(name)
+		if err != nil {
+			return nil, fmt.Errorf("invalid schema ID %v in %v: %w", key, filename, err)
+		}
+		mapping[state.ContextID{Context: idContext, ID: id}] = newID
+	}
+	return mapping, nil
+}
+
+func writeIDMapping(filename string, mapping map[state.ContextID]int) error {
+	values := make(map[any]int)
+	for id, newID := range mapping {
+		if id.Context == state.DefaultContext {
+			values[id.ID] = newID
+		} else {
+			values[state.Qualify(id.Context, strconv.Itoa(id.ID))] = newID
+		}
+	}
+	data, err := yaml.Marshal(values)
+	if err != nil {
+		return fmt.Errorf("unable to marshall yaml: %w", err)
+	}
+	err = os.WriteFile(filename, data, 0644)
+	if err != nil {
+		return fmt.Errorf("unable to write file %v: %w", filename, err)
+	}
+	return nil
+}
+
+// schemaIdentity identifies a schema by everything a registry considers when
+// deciding whether two schemas are the same, including their metadata and
+// rule set, since schemas that differ in those can't share an ID
+func schemaIdentity(schema sr.Schema) string {
+	return fmt.Sprintf("%v\x00%v\x00%v\x00%v\x00%v", schema.Type, schema.Schema, validation.Describe(schema.References),
+		state.Canonical(schema.SchemaMetadata), state.Canonical(schema.SchemaRuleSet))
+}
+
+func (p RemapIDsProcess) offsetMapping(s *state.State) map[state.ContextID]int {
+	mapping := make(map[state.ContextID]int)
+	for _, id := range s.IDs() {
+		mapping[id] = id.ID + p.Offset
+	}
+	return mapping
+}
+
+func (p RemapIDsProcess) nextFreeMapping(ctx context.Context, s *state.State) (map[state.ContextID]int, error) {
+	if p.Target == nil {
+		return nil, fmt.Errorf("next_free strategy needs a target registry")
+	}
+	err := p.Target.Connect()
+	if err != nil {
+		return nil, fmt.Errorf("unable to connect to target: %w", err)
+	}
+
+	targetSchemas, err := p.Target.AllSchemas(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to retrieve schemas from target: %w", err)
+	}
+	// Schema IDs are only unique within a context, so each context is
+	// numbered from its own highest ID
+	type contextSchema struct {
+		context  string
+		identity string
+	}
+	next := make(map[string]int)
+	targetIDs := make(map[contextSchema]int)
+	for _, subjectSchema := range targetSchemas {
+		targetContext := state.SubjectContext(subjectSchema.Subject)
+		next[targetContext] = max(next[targetContext], subjectSchema.ID)
+		targetIDs[contextSchema{context: targetContext, identity: schemaIdentity(subjectSchema.Schema)}] = subjectSchema.ID
+	}
+
+	// Schemas are numbered in state order, so that IDs keep their relative
+	// order, and identical schemas share an ID
+	mapping := make(map[state.ContextID]int)
+	for _, subjectSchema := range s.SubjectSchemas {
+		contextID := state.GetContextID(subjectSchema)
+		if _, ok := mapping[contextID]; ok {
+			continue
+		}
+		key := contextSchema{context: contextID.Context, identity: schemaIdentity(subjectSchema.Schema)}
+		if id, ok := targetIDs[key]; ok {
+			mapping[contextID] = id
+			continue
+		}
+		next[contextID.Context]++
+		mapping[contextID] = next[contextID.Context]
+		targetIDs[key] = next[contextID.Context]
+	}
+
+	// IDs that are only reserved still need a new ID of their own
+	for _, id := range s.IDs() {
+		if _, ok := mapping[id]; !ok {
+			next[id.Context]++
+			mapping[id] = next[id.Context]
+		}
+	}
+	return mapping, nil
+}
+
+func (p RemapIDsProcess) Process(ctx context.Context, s *state.State) (*state.State, error) {
+	var mapping map[state.ContextID]int
+	var err error
+	switch p.Strategy {
+	case "offset":
+		mapping = p.offsetMapping(s)
+	case "mapping":
+		mapping, err = ReadIDMapping(p.Mapping)
+	case "next_free":
+		mapping, err = p.nextFreeMapping(ctx, s)
+	default:
+		err = fmt.Errorf("unknown strategy: %v", p.Strategy)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to remap ids: %w", err)
+	}
+
+	report := validation.NewReport()
+	used := make(map[state.ContextID]int)
+	for _, id := range s.IDs() {
+		newID, ok := mapping[id]
+		if !ok {
+			report.Errorf("unmapped_id", state.Qualify(id.Context, ""), 0, "schema ID %v has no new ID in the mapping", id.ID)
+			continue
+		}
+		used[id] = newID
+	}
+	if err := report.Err(); err != nil {
+		return nil, err
+	}
+
+	// Two IDs can only become one if they are the same schema, as one of
+	// them would otherwise point at the wrong text. IDs with no schema, such
+	// as reserved IDs, can't be shared at all.
+	identities := make(map[state.ContextID]string)
+	for _, subjectSchema := range s.SubjectSchemas {
+		identities[state.GetContextID(subjectSchema)] = schemaIdentity(subjectSchema.Schema)
+	}
+	sources := make(map[state.ContextID]state.ContextID)
+	for _, id := range s.IDs() {
+		target := state.ContextID{Context: id.Context, ID: used[id]}
+		other, ok := sources[target]
+		if !ok {
+			sources[target] = id
+			continue
+		}
+		identity, found := identities[id]
+		otherIdentity, otherFound := identities[other]
+		if !found || !otherFound || identity != otherIdentity {
+			report.Errorf("id_collision", state.Qualify(id.Context, ""), 0, "schema IDs %v and %v would both become %v, but aren't the same schema", other.ID, id.ID, target.ID)
+		}
+	}
+	if err := report.Err(); err != nil {
+		return nil, err
+	}
+
+	if p.Output != "" {
+		err = writeIDMapping(p.Output, used)
+		if err != nil {
+			return nil, fmt.Errorf("unable to remap ids: %w", err)
+		}
+	}
+
+	return s.MapIDs(func(id state.ContextID) int {
+		return used[id]
+	}), nil
+}
@@ -0,0 +1,147 @@
+package process
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/twmb/franz-go/pkg/sr"
+	"go-schema-migrator/source"
+	"go-schema-migrator/state"
+)
+
+// remapped returns the subject version with its schema ID changed
+func remapped(subjectSchema sr.SubjectSchema, id int) sr.SubjectSchema {
+	subjectSchema.ID = id
+	return subjectSchema
+}
+
+func TestRemapIDs(t *testing.T) {
+	// Version 1 of c is the same schema as b under a different ID
+	duplicate := remapped(schema("b", 1, 2), 3)
+	duplicate.Subject = "c"
+
+	tests := []struct {
+		name     string
+		strategy string
+		offset   int
+		mapping  string
+		target   []sr.SubjectSchema
+		state    *state.State
+		want     *state.State
+		errors   []string
+	}{
+		{
+			name:     "offset",
+			strategy: "offset",
+			offset:   100,
+			state: &state.State{
+				SubjectSchemas: []sr.SubjectSchema{schema("a", 1, 1), schema("b", 1, 1), schema(":.x:a", 1, 1)},
+				HardDeletions:  []state.HardDeletion{{Subject: "a", Version: 2, ID: 2}},
+				ReservedIDs:    []int{3},
+			},
+			want: &state.State{
+				SubjectSchemas: []sr.SubjectSchema{remapped(schema("a", 1, 1), 101), remapped(schema("b", 1, 1), 101), remapped(schema(":.x:a", 1, 1), 101)},
+				HardDeletions:  []state.HardDeletion{{Subject: "a", Version: 2, ID: 102}},
+				ReservedIDs:    []int{103},
+			},
+		},
+		{
+			name:     "mapping is scoped to each context",
+			strategy: "mapping",
+			mapping:  "1: 10\n2: 20\n\":.x:1\": 5\n",
+			state: &state.State{
+				SubjectSchemas: []sr.SubjectSchema{schema("a", 1, 1), schema("b", 1, 2), schema(":.x:a", 1, 1)},
+			},
+			want: &state.State{
+				SubjectSchemas: []sr.SubjectSchema{remapped(schema("a", 1, 1), 10), remapped(schema("b", 1, 2), 20), remapped(schema(":.x:a", 1, 1), 5)},
+			},
+		},
+		{
+			name:     "mapping the same schema to one ID",
+			strategy: "mapping",
+			mapping:  "2: 5\n3: 5\n",
+			state: &state.State{
+				SubjectSchemas: []sr.SubjectSchema{schema("b", 1, 2), duplicate},
+			},
+			want: &state.State{
+				SubjectSchemas: []sr.SubjectSchema{remapped(schema("b", 1, 2), 5), remapped(duplicate, 5)},
+			},
+		},
+		{
+			name:     "mapping different schemas to one ID is an error",
+			strategy: "mapping",
+			mapping:  "1: 5\n2: 5\n",
+			state: &state.State{
+				SubjectSchemas: []sr.SubjectSchema{schema("a", 1, 1), schema("b", 1, 2)},
+			},
+			errors: []string{"id_collision /0"},
+		},
+		{
+			name:     "an ID missing from the mapping is an error",
+			strategy: "mapping",
+			mapping:  "1: 5\n",
+			state: &state.State{
+				SubjectSchemas: []sr.SubjectSchema{schema("a", 1, 1), schema("b", 1, 2), schema(":.x:b", 1, 2)},
+			},
+			errors: []string{"unmapped_id /0", "unmapped_id :.x:/0"},
+		},
+		{
+			name:     "next_free shares an ID for identical text",
+			strategy: "next_free",
+			target:   []sr.SubjectSchema{remapped(schema("t", 1, 1), 7), schema("u", 1, 9)},
+			state: &state.State{
+				SubjectSchemas: []sr.SubjectSchema{schema("a", 1, 1), schema("b", 1, 2), duplicate, schema(":.x:d", 1, 1)},
+				ReservedIDs:    []int{4},
+			},
+			want: &state.State{
+				SubjectSchemas: []sr.SubjectSchema{
+					remapped(schema("a", 1, 1), 7),
+					remapped(schema("b", 1, 2), 10),
+					remapped(duplicate, 10),
+					schema(":.x:d", 1, 1),
+				},
+				ReservedIDs: []int{11},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := RemapIDsProcess{Strategy: tt.strategy, Offset: tt.offset}
+			if tt.mapping != "" {
+				p.Mapping = filepath.Join(t.TempDir(), "ids.yaml")
+				if err := os.WriteFile(p.Mapping, []byte(tt.mapping), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tt.target != nil {
+				server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					_ = json.NewEncoder(w).Encode(tt.target)
+				}))
+				defer server.Close()
+				p.Target = &source.RestSource{URL: server.URL}
+			}
+
+			got, err := p.Process(context.Background(), tt.state)
+			if tt.errors != nil {
+				if got != nil {
+					t.Errorf("got a state, want none")
+				}
+				if got := checks(t, err); !slices.Equal(got, tt.errors) {
+					t.Errorf("got %v, want %v", got, tt.errors)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkState(t, got, tt.want)
+		})
+	}
+}
@@ -0,0 +1,15 @@
+package process
+
+import (
+	"context"
+	"go-schema-migrator/state"
+)
+
+type RemoveMetadataProcess struct{}
+
+func (f RemoveMetadataProcess) Process(ctx context.Context, s *state.State) (*state.State, error) {
+	for i := range s.SubjectSchemas {
+		s.SubjectSchemas[i].SchemaMetadata = nil
+	}
+	return s, nil
+}
@@ -0,0 +1,23 @@
+package process
+
+import (
+	"context"
+	"go-schema-migrator/state"
+)
+
+type RemoveRuleSetsProcess struct{}
+
+func (f RemoveRuleSetsProcess) Process(ctx context.Context, s *state.State) (*state.State, error) {
+	for i := range s.SubjectSchemas {
+		s.SubjectSchemas[i].SchemaRuleSet = nil
+	}
+	for i := range s.CompatibilityResults {
+		s.CompatibilityResults[i].DefaultRuleSet = nil
+		s.CompatibilityResults[i].OverrideRuleSet = nil
+	}
+	if s.GlobalCompatibility != nil {
+		s.GlobalCompatibility.DefaultRuleSet = nil
+		s.GlobalCompatibility.OverrideRuleSet = nil
+	}
+	return s, nil
+}
@@ -0,0 +1,114 @@
+package process
+
+import (
+	"context"
+	"fmt"
+	"go-schema-migrator/state"
+	"go-schema-migrator/validation"
+	"regexp"
+	"strings"
+)
+
+// RenameRule changes a subject name in exactly one way: replacing matches of
+// a regex (which may use $1 style references to its groups), adding a prefix
+// or suffix, or stripping a prefix or suffix where it is present
+type RenameRule struct {
+	Regex       string `koanf:"regex"`
+	Replacement string `koanf:"replacement"`
+	AddPrefix   string `koanf:"add_prefix"`
+	AddSuffix   string `koanf:"add_suffix"`
+	StripPrefix string `koanf:"strip_prefix"`
+	StripSuffix string `koanf:"strip_suffix"`
+}
+
+func (r RenameRule) compile() (func(string) string, error) {
+	set := 0
+	for _, option := range []string{r.Regex, r.AddPrefix, r.AddSuffix, r.StripPrefix, r.StripSuffix} {
+		if option != "" {
+			set++
+		}
+	}
+	if set != 1 {
+		return nil, fmt.Errorf("rename rule must have exactly one of regex, add_prefix, add_suffix, strip_prefix or strip_suffix")
+	}
+
+	switch {
+	case r.Regex != "":
+		re, err := regexp.Compile(r.Regex)
+		if err != nil {
+			return nil, fmt.Errorf("unable to compile regex %v: %w", r.Regex, err)
+		}
+		return func(name string) string {
+			return re.ReplaceAllString(name, r.Replacement)
+		}, nil
+	case r.AddPrefix != "":
+		return func(name string) string {
+			return r.AddPrefix + name
+		}, nil
+	case r.AddSuffix != "":
+		return func(name string) string {
+			return name + r.AddSuffix
+		}, nil
+	case r.StripPrefix != "":
+		return func(name string) string {
+			return strings.TrimPrefix(name, r.StripPrefix)
+		}, nil
+	default:
+		return func(name string) string {
+			return strings.TrimSuffix(name, r.StripSuffix)
+		}, nil
+	}
+}
+
+// RenameSubjectsProcess applies its rules, in order, to the name of every
+// subject. A subject keeps its context, and references, deletions,
+// compatibility levels and modes follow the subjects they name.
+type RenameSubjectsProcess struct {
+	Rules []RenameRule `koanf:"rules"`
+}
+
+func (p RenameSubjectsProcess) Process(ctx context.Context, s *state.State) (*state.State, error) {
+	rules := make([]func(string) string, 0, len(p.Rules))
+	for i, rule := range p.Rules {
+		fn, err := rule.compile()
+		if err != nil {
+			return nil, fmt.Errorf("unable to rename subjects: rule %v: %w", i, err)
+		}
+		rules = append(rules, fn)
+	}
+
+	rename := func(subject string) string {
+		subjectContext, name := state.SplitContext(subject)
+		// The configuration of a context is held against the context itself
+		if name == "" {
+			return subject
+		}
+		for _, rule := range rules {
+			name = rule(name)
+		}
+		return state.Qualify(subjectContext, name)
+	}
+
+	report := validation.NewReport()
+	renamedFrom := make(map[string]string)
+	for _, subject := range s.Subjects() {
+		if state.IsContextSubject(subject) {
+			continue
+		}
+		renamed := rename(subject)
+		if renamed == "" || state.IsContextSubject(renamed) {
+			report.Errorf("empty_subject", subject, 0, "subject is renamed to an empty name")
+			continue
+		}
+		if existing, ok := renamedFrom[renamed]; ok {
+			report.Errorf("subject_collision", subject, 0, "subject is renamed to %v, as is subject %v", renamed, existing)
+			continue
+		}
+		renamedFrom[renamed] = subject
+	}
+	if err := report.Err(); err != nil {
+		return s, err
+	}
+
+	return s.MapSubjects(rename), nil
+}
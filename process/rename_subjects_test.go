@@ -0,0 +1,95 @@
+package process
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/twmb/franz-go/pkg/sr"
+	"go-schema-migrator/state"
+)
+
+func TestRenameSubjects(t *testing.T) {
+	tests := []struct {
+		name   string
+		rules  []RenameRule
+		state  *state.State
+		want   *state.State
+		errors []string
+	}{
+		{
+			name:  "a reference to a renamed subject is rewritten, keeping its name",
+			rules: []RenameRule{{AddPrefix: "team-"}},
+			state: &state.State{
+				SubjectSchemas: []sr.SubjectSchema{schema("b", 1, 1), schema("a", 1, 2, reference("b", 1))},
+			},
+			want: &state.State{
+				SubjectSchemas: []sr.SubjectSchema{schema("team-b", 1, 1), schema("team-a", 1, 2, sr.SchemaReference{Name: "b", Subject: "team-b", Version: 1})},
+			},
+		},
+		{
+			name:  "deletions and configuration follow the subject",
+			rules: []RenameRule{{Regex: "^(.*)-value$", Replacement: "${1}-v2"}},
+			state: &state.State{
+				SubjectSchemas:       []sr.SubjectSchema{schema("a-value", 1, 1), schema("b-value", 2, 2)},
+				SoftDeletions:        []sr.SubjectVersion{{Subject: "b-value", Version: 2}},
+				DeletedSubjects:      []string{"b-value"},
+				HardDeletions:        []state.HardDeletion{{Subject: "b-value", Version: 1, ID: 3}},
+				CompatibilityResults: []sr.CompatibilityResult{{Subject: "a-value", Level: sr.CompatFull}},
+				Modes:                []sr.ModeResult{{Subject: "b-value", Mode: sr.ModeReadOnly}},
+			},
+			want: &state.State{
+				SubjectSchemas:       []sr.SubjectSchema{schema("a-v2", 1, 1), schema("b-v2", 2, 2)},
+				SoftDeletions:        []sr.SubjectVersion{{Subject: "b-v2", Version: 2}},
+				DeletedSubjects:      []string{"b-v2"},
+				HardDeletions:        []state.HardDeletion{{Subject: "b-v2", Version: 1, ID: 3}},
+				CompatibilityResults: []sr.CompatibilityResult{{Subject: "a-v2", Level: sr.CompatFull}},
+				Modes:                []sr.ModeResult{{Subject: "b-v2", Mode: sr.ModeReadOnly}},
+			},
+		},
+		{
+			name:  "a subject keeps its context",
+			rules: []RenameRule{{StripPrefix: "legacy."}},
+			state: &state.State{
+				SubjectSchemas:       []sr.SubjectSchema{schema(":.orders:legacy.b", 1, 1), schema(":.orders:legacy.a", 1, 2, reference("legacy.b", 1))},
+				CompatibilityResults: []sr.CompatibilityResult{{Subject: ":.orders:", Level: sr.CompatNone}},
+			},
+			want: &state.State{
+				SubjectSchemas:       []sr.SubjectSchema{schema(":.orders:b", 1, 1), schema(":.orders:a", 1, 2, sr.SchemaReference{Name: "legacy.b", Subject: "b", Version: 1})},
+				CompatibilityResults: []sr.CompatibilityResult{{Subject: ":.orders:", Level: sr.CompatNone}},
+			},
+		},
+		{
+			name:  "two subjects renamed to the same name are an error",
+			rules: []RenameRule{{StripSuffix: "-v2"}},
+			state: &state.State{
+				SubjectSchemas: []sr.SubjectSchema{schema("a", 1, 1), schema("a-v2", 1, 2)},
+			},
+			errors: []string{"subject_collision a-v2/0"},
+		},
+		{
+			name:  "a subject renamed to nothing is an error",
+			rules: []RenameRule{{Regex: ".*", Replacement: ""}},
+			state: &state.State{
+				SubjectSchemas: []sr.SubjectSchema{schema("a", 1, 1)},
+			},
+			errors: []string{"empty_subject a/0"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := RenameSubjectsProcess{Rules: tt.rules}.Process(context.Background(), tt.state)
+			if tt.errors != nil {
+				if got := checks(t, err); !slices.Equal(got, tt.errors) {
+					t.Errorf("got %v, want %v", got, tt.errors)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkState(t, got, tt.want)
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package process
+
+import (
+	"context"
+	"fmt"
+	"github.com/twmb/franz-go/pkg/sr"
+	"go-schema-migrator/internal/collections"
+	"go-schema-migrator/state"
+	"maps"
+	"slices"
+)
+
+// RenumberVersionsProcess compacts the versions of each selected subject so
+// that they run from 1 without gaps. SoftDeleted decides whether soft deleted
+// versions are renumbered along with the rest ("keep", the default) or
+// dropped ("drop"), although a soft deleted version that a remaining schema
+// references is always kept.
+type RenumberVersionsProcess struct {
+	Include     []SubjectMatcher `koanf:"include"`
+	Exclude     []SubjectMatcher `koanf:"exclude"`
+	SoftDeleted string           `koanf:"soft_deleted"`
+}
+
+func (p RenumberVersionsProcess) Process(ctx context.Context, s *state.State) (*state.State, error) {
+	subjectFilter, err := newSubjectFilter(p.Include, p.Exclude)
+	if err != nil {
+		return nil, fmt.Errorf("unable to renumber versions: %w", err)
+	}
+	softDeleted := p.SoftDeleted
+	if softDeleted == "" {
+		softDeleted = "keep"
+	}
+	if softDeleted != "keep" && softDeleted != "drop" {
+		return nil, fmt.Errorf("unable to renumber versions: unknown soft_deleted option: %v", softDeleted)
+	}
+
+	// Work out which versions are dropped, bringing back any that a kept
+	// schema references
+	softDeletions := collections.ToMap(s.SoftDeletions)
+	index := make(map[sr.SubjectVersion]sr.SubjectSchema)
+	dropped := make(map[sr.SubjectVersion]bool)
+	kept := make([]sr.SubjectSchema, 0)
+	for _, subjectSchema := range s.SubjectSchemas {
+		reference := state.GetReference(subjectSchema)
+		index[reference] = subjectSchema
+		if softDeleted == "drop" && softDeletions[reference] && subjectFilter.matches(subjectSchema.Subject) {
+			dropped[reference] = true
+			continue
+		}
+		kept = append(kept, subjectSchema)
+	}
+	for reference := range s.ReferencedBy(kept) {
+		delete(dropped, reference)
+	}
+
+	versions := make(map[string][]int)
+	for _, subjectSchema := range s.SubjectSchemas {
+		if subjectFilter.matches(subjectSchema.Subject) && !dropped[state.GetReference(subjectSchema)] {
+			versions[subjectSchema.Subject] = append(versions[subjectSchema.Subject], subjectSchema.Version)
+		}
+	}
+	renumbered := make(map[sr.SubjectVersion]int)
+	for subject, subjectVersions := range versions {
+		slices.Sort(subjectVersions)
+		for i, version := range subjectVersions {
+			renumbered[sr.SubjectVersion{Subject: subject, Version: version}] = i + 1
+		}
+	}
+	renumber := func(reference sr.SubjectVersion) int {
+		if version, ok := renumbered[reference]; ok {
+			return version
+		}
+		return reference.Version
+	}
+
+	result := *s
+
+	result.SubjectSchemas = make([]sr.SubjectSchema, 0, len(s.SubjectSchemas))
+	for _, subjectSchema := range s.SubjectSchemas {
+		if dropped[state.GetReference(subjectSchema)] {
+			continue
+		}
+		if subjectSchema.References != nil {
+			references := make([]sr.SchemaReference, 0, len(subjectSchema.References))
+			for _, schemaReference := range subjectSchema.References {
+				schemaReference.Version = renumber(state.ResolveReference(subjectSchema, schemaReference))
+				references = append(references, schemaReference)
+			}
+			subjectSchema.References = references
+		}
+		subjectSchema.Version = renumber(state.GetReference(subjectSchema))
+		result.SubjectSchemas = append(result.SubjectSchemas, subjectSchema)
+	}
+
+	result.SoftDeletions = make([]sr.SubjectVersion, 0, len(s.SoftDeletions))
+	for _, deletion := range s.SoftDeletions {
+		if !dropped[deletion] {
+			result.SoftDeletions = append(result.SoftDeletions, sr.SubjectVersion{Subject: deletion.Subject, Version: renumber(deletion)})
+		}
+	}
+
+	remaining := make(map[string]bool)
+	for _, subjectSchema := range result.SubjectSchemas {
+		remaining[subjectSchema.Subject] = true
+	}
+	result.DeletedSubjects = collections.Filter(s.DeletedSubjects, func(subject string) bool {
+		return remaining[subject] || !subjectFilter.matches(subject)
+	})
+
+	// The versions of hard deletions no longer exist once a subject has been
+	// renumbered, so their IDs, and those of dropped versions, are reserved
+	// instead, unless a remaining schema still uses them
+	inUse := make(map[int]bool)
+	for _, subjectSchema := range result.SubjectSchemas {
+		inUse[subjectSchema.ID] = true
+	}
+	reserved := collections.ToMap(s.ReservedIDs)
+	result.HardDeletions = make([]state.HardDeletion, 0, len(s.HardDeletions))
+	for _, deletion := range s.HardDeletions {
+		if !subjectFilter.matches(deletion.Subject) {
+			result.HardDeletions = append(result.HardDeletions, deletion)
+			continue
+		}
+		if !inUse[deletion.ID] {
+			reserved[deletion.ID] = true
+		}
+	}
+	for reference := range dropped {
+		if !inUse[index[reference].ID] {
+			reserved[index[reference].ID] = true
+		}
+	}
+	result.ReservedIDs = slices.Sorted(maps.Keys(reserved))
+
+	return &result, nil
+}
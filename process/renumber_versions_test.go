@@ -0,0 +1,79 @@
+package process
+
+import (
+	"context"
+	"testing"
+
+	"github.com/twmb/franz-go/pkg/sr"
+	"go-schema-migrator/state"
+)
+
+func TestRenumberVersions(t *testing.T) {
+	tests := []struct {
+		name    string
+		process RenumberVersionsProcess
+		state   *state.State
+		want    *state.State
+	}{
+		{
+			name: "gaps are closed, and a reference to a renumbered version follows it",
+			state: &state.State{
+				SubjectSchemas: []sr.SubjectSchema{schema("a", 1, 1), schema("a", 3, 3), schema("b", 1, 4, reference("a", 3))},
+				HardDeletions:  []state.HardDeletion{{Subject: "a", Version: 2, ID: 2}},
+			},
+			want: &state.State{
+				SubjectSchemas: []sr.SubjectSchema{schema("a", 1, 1), schema("a", 2, 3), schema("b", 1, 4, reference("a", 2))},
+				ReservedIDs:    []int{2},
+			},
+		},
+		{
+			name: "soft deletions and deleted subjects follow a renumbered version",
+			state: &state.State{
+				SubjectSchemas:  []sr.SubjectSchema{schema("a", 2, 1), schema("a", 5, 2)},
+				SoftDeletions:   []sr.SubjectVersion{{Subject: "a", Version: 2}, {Subject: "a", Version: 5}},
+				DeletedSubjects: []string{"a"},
+			},
+			want: &state.State{
+				SubjectSchemas:  []sr.SubjectSchema{schema("a", 1, 1), schema("a", 2, 2)},
+				SoftDeletions:   []sr.SubjectVersion{{Subject: "a", Version: 1}, {Subject: "a", Version: 2}},
+				DeletedSubjects: []string{"a"},
+			},
+		},
+		{
+			name:    "soft deleted versions are dropped unless they are referenced",
+			process: RenumberVersionsProcess{SoftDeleted: "drop"},
+			state: &state.State{
+				SubjectSchemas: []sr.SubjectSchema{schema("a", 1, 1), schema("a", 2, 2), schema("a", 3, 3), schema("b", 1, 4, reference("a", 3))},
+				SoftDeletions:  []sr.SubjectVersion{{Subject: "a", Version: 1}, {Subject: "a", Version: 3}},
+			},
+			want: &state.State{
+				SubjectSchemas: []sr.SubjectSchema{schema("a", 1, 2), schema("a", 2, 3), schema("b", 1, 4, reference("a", 2))},
+				SoftDeletions:  []sr.SubjectVersion{{Subject: "a", Version: 2}},
+				ReservedIDs:    []int{1},
+			},
+		},
+		{
+			name:    "subjects that aren't selected keep their versions",
+			process: RenumberVersionsProcess{Exclude: []SubjectMatcher{{Glob: "b"}}},
+			state: &state.State{
+				SubjectSchemas: []sr.SubjectSchema{schema("a", 2, 1), schema("b", 2, 2, reference("a", 2))},
+				HardDeletions:  []state.HardDeletion{{Subject: "a", Version: 1, ID: 3}, {Subject: "b", Version: 1, ID: 4}},
+			},
+			want: &state.State{
+				SubjectSchemas: []sr.SubjectSchema{schema("a", 1, 1), schema("b", 2, 2, reference("a", 1))},
+				HardDeletions:  []state.HardDeletion{{Subject: "b", Version: 1, ID: 4}},
+				ReservedIDs:    []int{3},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.process.Process(context.Background(), tt.state)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkState(t, got, tt.want)
+		})
+	}
+}
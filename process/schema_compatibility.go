@@ -0,0 +1,335 @@
+package process
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/bufbuild/protocompile/linker"
+	"github.com/hamba/avro/v2"
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"github.com/twmb/franz-go/pkg/sr"
+	"go-schema-migrator/state"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// parseForCompatibility parses a schema into what its compatibility check
+// works on: an Avro schema, a compiled Protobuf file, or a JSON document
+func (p schemaParser) parseForCompatibility(subjectSchema sr.SubjectSchema) (any, error) {
+	switch subjectSchema.Type {
+	case sr.TypeAvro:
+		return p.parseAvro(subjectSchema)
+	case sr.TypeProtobuf:
+		return p.parseProtobuf(subjectSchema)
+	case sr.TypeJSON:
+		return jsonschema.UnmarshalJSON(strings.NewReader(subjectSchema.Schema.Schema))
+	}
+	return nil, fmt.Errorf("unknown schema type %v", subjectSchema.Type)
+}
+
+// checkCompatible returns the reasons that data written with the writer schema
+// can't be read with the reader schema, if any
+func checkCompatible(schemaType sr.SchemaType, reader any, writer any) []string {
+	switch schemaType {
+	case sr.TypeAvro:
+		err := avro.NewSchemaCompatibility().Compatible(reader.(avro.Schema), writer.(avro.Schema))
+		if err != nil {
+			return []string{err.Error()}
+		}
+		return nil
+	case sr.TypeProtobuf:
+		return checkProtobufCompatible(reader.(linker.File), writer.(linker.File))
+	case sr.TypeJSON:
+		return checkJSONCompatible("#", reader, writer)
+	}
+	return []string{fmt.Sprintf("unknown schema type %v", schemaType)}
+}
+
+// protobufWireType groups field kinds that can be read as each other. Enums
+// and messages only match the same named type.
+func protobufWireType(field protoreflect.FieldDescriptor) string {
+	switch field.Kind() {
+	case protoreflect.Int32Kind, protoreflect.Uint32Kind, protoreflect.Int64Kind, protoreflect.Uint64Kind, protoreflect.BoolKind:
+		return "varint"
+	case protoreflect.Sint32Kind, protoreflect.Sint64Kind:
+		return "zigzag"
+	case protoreflect.Fixed32Kind, protoreflect.Sfixed32Kind:
+		return "fixed32"
+	case protoreflect.Fixed64Kind, protoreflect.Sfixed64Kind:
+		return "fixed64"
+	case protoreflect.StringKind, protoreflect.BytesKind:
+		return "bytes"
+	case protoreflect.EnumKind:
+		return "enum " + string(field.Enum().FullName())
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return "message " + string(field.Message().FullName())
+	}
+	return field.Kind().String()
+}
+
+// protobufMessages returns every message in a file, including nested ones
+func protobufMessages(file protoreflect.FileDescriptor) map[protoreflect.FullName]protoreflect.MessageDescriptor {
+	result := make(map[protoreflect.FullName]protoreflect.MessageDescriptor)
+	var add func(messages protoreflect.MessageDescriptors)
+	add = func(messages protoreflect.MessageDescriptors) {
+		for i := 0; i < messages.Len(); i++ {
+			message := messages.Get(i)
+			if message.IsMapEntry() {
+				continue
+			}
+			result[message.FullName()] = message
+			add(message.Messages())
+		}
+	}
+	add(file.Messages())
+	return result
+}
+
+// checkProtobufCompatible applies the basic Protobuf rules: messages and
+// required fields must be kept, field numbers must keep a compatible type, and
+// fields must not move out of a oneof, or several at once into one
+func checkProtobufCompatible(reader linker.File, writer linker.File) []string {
+	problems := make([]string, 0)
+	if reader.Package() != writer.Package() {
+		problems = append(problems, fmt.Sprintf("package %q doesn't match the writer's %q", reader.Package(), writer.Package()))
+	}
+
+	readerMessages := protobufMessages(reader)
+	writerMessages := protobufMessages(writer)
+	for _, name := range slices.Sorted(maps.Keys(writerMessages)) {
+		writerMessage := writerMessages[name]
+		readerMessage, ok := readerMessages[name]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("message %v is missing", name))
+			continue
+		}
+
+		movedToOneof := make(map[protoreflect.FullName]int)
+		writerFields := writerMessage.Fields()
+		for i := 0; i < writerFields.Len(); i++ {
+			writerField := writerFields.Get(i)
+			readerField := readerMessage.Fields().ByNumber(writerField.Number())
+			if readerField == nil {
+				if writerField.Cardinality() == protoreflect.Required {
+					problems = append(problems, fmt.Sprintf("required field %v.%v is missing", name, writerField.Name()))
+				}
+				continue
+			}
+			if protobufWireType(readerField) != protobufWireType(writerField) {
+				problems = append(problems, fmt.Sprintf("field %v.%v type %v doesn't match the writer's %v", name, writerField.Name(), protobufWireType(readerField), protobufWireType(writerField)))
+			}
+			writerOneof := writerField.ContainingOneof()
+			readerOneof := readerField.ContainingOneof()
+			if writerOneof != nil && !writerOneof.IsSynthetic() && (readerOneof == nil || readerOneof.IsSynthetic()) {
+				problems = append(problems, fmt.Sprintf("field %v.%v isn't in oneof %v as it is in the writer", name, writerField.Name(), writerOneof.Name()))
+			}
+			if (writerOneof == nil || writerOneof.IsSynthetic()) && readerOneof != nil && !readerOneof.IsSynthetic() {
+				movedToOneof[readerOneof.FullName()]++
+			}
+		}
+		for _, oneof := range slices.Sorted(maps.Keys(movedToOneof)) {
+			if movedToOneof[oneof] > 1 {
+				problems = append(problems, fmt.Sprintf("%v fields are in oneof %v but not in the writer", movedToOneof[oneof], oneof))
+			}
+		}
+
+		readerFields := readerMessage.Fields()
+		for i := 0; i < readerFields.Len(); i++ {
+			readerField := readerFields.Get(i)
+			if readerField.Cardinality() == protoreflect.Required && writerFields.ByNumber(readerField.Number()) == nil {
+				problems = append(problems, fmt.Sprintf("required field %v.%v isn't in the writer", name, readerField.Name()))
+			}
+		}
+	}
+	return problems
+}
+
+// jsonTypes returns the types that a JSON schema allows, or nil for any type
+func jsonTypes(schema map[string]any) []string {
+	switch value := schema["type"].(type) {
+	case string:
+		return []string{value}
+	case []any:
+		types := make([]string, 0)
+		for _, t := range value {
+			if s, ok := t.(string); ok {
+				types = append(types, s)
+			}
+		}
+		return types
+	}
+	return nil
+}
+
+func jsonNumber(value any) (float64, bool) {
+	switch number := value.(type) {
+	case json.Number:
+		f, err := number.Float64()
+		return f, err == nil
+	case float64:
+		return number, true
+	}
+	return 0, false
+}
+
+func jsonStrings(value any) map[string]bool {
+	result := make(map[string]bool)
+	values, _ := value.([]any)
+	for _, v := range values {
+		if s, ok := v.(string); ok {
+			result[s] = true
+		}
+	}
+	return result
+}
+
+// jsonAllows reports whether a JSON schema (such as additionalProperties)
+// allows any value, rather than being false
+func jsonAllows(schema any) bool {
+	allowed, ok := schema.(bool)
+	return !ok || allowed
+}
+
+// checkJSONCompatible applies the basic JSON Schema rules at path: the reader
+// must allow every type, enum value and property that the writer allows, and
+// mustn't narrow any bounds or require anything new
+func checkJSONCompatible(path string, reader any, writer any) []string {
+	readerSchema, readerIsObject := reader.(map[string]any)
+	writerSchema, writerIsObject := writer.(map[string]any)
+	// A boolean schema allows either every value or none
+	if !readerIsObject {
+		if !jsonAllows(reader) && jsonAllows(writer) {
+			return []string{fmt.Sprintf("%v: doesn't allow any value", path)}
+		}
+		return nil
+	}
+	if !writerIsObject {
+		if !jsonAllows(writer) {
+			return nil
+		}
+		writerSchema = map[string]any{}
+	}
+
+	problems := make([]string, 0)
+	if !state.Equivalent(readerSchema["$ref"], writerSchema["$ref"]) {
+		problems = append(problems, fmt.Sprintf("%v: reference %v doesn't match the writer's %v", path, readerSchema["$ref"], writerSchema["$ref"]))
+	}
+
+	// The reader must allow every type the writer does, where a number allows integers
+	readerTypes := jsonTypes(readerSchema)
+	writerTypes := jsonTypes(writerSchema)
+	if readerTypes != nil {
+		if writerTypes == nil {
+			problems = append(problems, fmt.Sprintf("%v: only allows type %v", path, strings.Join(readerTypes, ", ")))
+		}
+		for _, writerType := range writerTypes {
+			if !slices.Contains(readerTypes, writerType) && !(writerType == "integer" && slices.Contains(readerTypes, "number")) {
+				problems = append(problems, fmt.Sprintf("%v: doesn't allow type %v", path, writerType))
+			}
+		}
+	}
+
+	if _, ok := readerSchema["enum"]; ok {
+		readerEnum := jsonStrings(readerSchema["enum"])
+		writerEnum := jsonStrings(writerSchema["enum"])
+		if _, ok := writerSchema["enum"]; !ok {
+			problems = append(problems, fmt.Sprintf("%v: has an enum where the writer doesn't", path))
+		}
+		for _, value := range slices.Sorted(maps.Keys(writerEnum)) {
+			if !readerEnum[value] {
+				problems = append(problems, fmt.Sprintf("%v: enum value %q is missing", path, value))
+			}
+		}
+	}
+
+	// Bounds may be widened but not narrowed
+	for _, keyword := range []string{"maxLength", "maxItems", "maxProperties", "maximum", "exclusiveMaximum"} {
+		readerBound, readerOk := jsonNumber(readerSchema[keyword])
+		writerBound, writerOk := jsonNumber(writerSchema[keyword])
+		if readerOk && (!writerOk || readerBound < writerBound) {
+			problems = append(problems, fmt.Sprintf("%v: %v %v is narrower than the writer's", path, keyword, readerBound))
+		}
+	}
+	for _, keyword := range []string{"minLength", "minItems", "minProperties", "minimum", "exclusiveMinimum"} {
+		readerBound, readerOk := jsonNumber(readerSchema[keyword])
+		writerBound, writerOk := jsonNumber(writerSchema[keyword])
+		if readerOk && (!writerOk || readerBound > writerBound) {
+			problems = append(problems, fmt.Sprintf("%v: %v %v is narrower than the writer's", path, keyword, readerBound))
+		}
+	}
+
+	// Properties are checked against the content model: a property added to
+	// an open model may already have been written with any value, and one
+	// removed from a closed model is no longer allowed at all
+	readerProperties, _ := readerSchema["properties"].(map[string]any)
+	writerProperties, _ := writerSchema["properties"].(map[string]any)
+	readerAdditional, readerHasAdditional := readerSchema["additionalProperties"]
+	writerAdditional, writerHasAdditional := writerSchema["additionalProperties"]
+	if !jsonAllows(readerAdditional) && jsonAllows(writerAdditional) {
+		problems = append(problems, fmt.Sprintf("%v: additional properties aren't allowed", path))
+	}
+	for _, name := range slices.Sorted(maps.Keys(writerProperties)) {
+		readerProperty, ok := readerProperties[name]
+		if !ok {
+			if !jsonAllows(readerAdditional) {
+				problems = append(problems, fmt.Sprintf("%v: property %v isn't allowed by the closed content model", path, name))
+			} else if readerHasAdditional {
+				problems = append(problems, checkJSONCompatible(path+"/properties/"+name, readerAdditional, writerProperties[name])...)
+			}
+			continue
+		}
+		problems = append(problems, checkJSONCompatible(path+"/properties/"+name, readerProperty, writerProperties[name])...)
+	}
+	for _, name := range slices.Sorted(maps.Keys(readerProperties)) {
+		if _, ok := writerProperties[name]; ok {
+			continue
+		}
+		if !writerHasAdditional {
+			problems = append(problems, fmt.Sprintf("%v: property %v isn't in the writer's open content model", path, name))
+		} else if jsonAllows(writerAdditional) {
+			problems = append(problems, checkJSONCompatible(path+"/properties/"+name, readerProperties[name], writerAdditional)...)
+		}
+	}
+
+	readerRequired := jsonStrings(readerSchema["required"])
+	writerRequired := jsonStrings(writerSchema["required"])
+	for _, name := range slices.Sorted(maps.Keys(readerRequired)) {
+		if !writerRequired[name] {
+			problems = append(problems, fmt.Sprintf("%v: property %v is required but not by the writer", path, name))
+		}
+	}
+
+	if readerItems, ok := readerSchema["items"]; ok {
+		writerItems, ok := writerSchema["items"]
+		if !ok {
+			writerItems = true
+		}
+		problems = append(problems, checkJSONCompatible(path+"/items", readerItems, writerItems)...)
+	}
+
+	// Every alternative the writer could have used must be readable by one of
+	// the reader's alternatives
+	for _, keyword := range []string{"anyOf", "oneOf"} {
+		readerAlternatives, ok := readerSchema[keyword].([]any)
+		if !ok {
+			continue
+		}
+		writerAlternatives, ok := writerSchema[keyword].([]any)
+		if !ok {
+			writerAlternatives = []any{writerSchema}
+		}
+		for i, writerAlternative := range writerAlternatives {
+			if !slices.ContainsFunc(readerAlternatives, func(readerAlternative any) bool {
+				return len(checkJSONCompatible(path, readerAlternative, writerAlternative)) == 0
+			}) {
+				problems = append(problems, fmt.Sprintf("%v: no alternative in %v can read the writer's alternative %v", path, keyword, i))
+			}
+		}
+	}
+	if !state.Equivalent(readerSchema["allOf"], writerSchema["allOf"]) && readerSchema["allOf"] != nil {
+		problems = append(problems, fmt.Sprintf("%v: allOf doesn't match the writer's", path))
+	}
+
+	return problems
+}
@@ -0,0 +1,248 @@
+package process
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/twmb/franz-go/pkg/sr"
+	"go-schema-migrator/state"
+	"go-schema-migrator/validation"
+)
+
+func TestCheckCompatible(t *testing.T) {
+	tests := []struct {
+		name       string
+		schemaType sr.SchemaType
+		reader     string
+		writer     string
+		want       []string
+	}{
+		{
+			name:       "avro field added with a default",
+			schemaType: sr.TypeAvro,
+			reader:     `{"type":"record","name":"R","fields":[{"name":"a","type":"string"},{"name":"b","type":"int","default":0}]}`,
+			writer:     `{"type":"record","name":"R","fields":[{"name":"a","type":"string"}]}`,
+		},
+		{
+			name:       "avro field added without a default",
+			schemaType: sr.TypeAvro,
+			reader:     `{"type":"record","name":"R","fields":[{"name":"a","type":"string"},{"name":"b","type":"int"}]}`,
+			writer:     `{"type":"record","name":"R","fields":[{"name":"a","type":"string"}]}`,
+			want:       []string{"b"},
+		},
+		{
+			name:       "avro field removed",
+			schemaType: sr.TypeAvro,
+			reader:     `{"type":"record","name":"R","fields":[{"name":"a","type":"string"}]}`,
+			writer:     `{"type":"record","name":"R","fields":[{"name":"a","type":"string"},{"name":"b","type":"int"}]}`,
+		},
+		{
+			name:       "avro type promoted",
+			schemaType: sr.TypeAvro,
+			reader:     `{"type":"record","name":"R","fields":[{"name":"a","type":"long"}]}`,
+			writer:     `{"type":"record","name":"R","fields":[{"name":"a","type":"int"}]}`,
+		},
+		{
+			name:       "protobuf field added",
+			schemaType: sr.TypeProtobuf,
+			reader:     `syntax = "proto3"; message M { string a = 1; int32 b = 2; }`,
+			writer:     `syntax = "proto3"; message M { string a = 1; }`,
+		},
+		{
+			name:       "protobuf field changed within its wire type",
+			schemaType: sr.TypeProtobuf,
+			reader:     `syntax = "proto3"; message M { int64 a = 1; bytes b = 2; }`,
+			writer:     `syntax = "proto3"; message M { int32 a = 1; string b = 2; }`,
+		},
+		{
+			name:       "protobuf wire type changed",
+			schemaType: sr.TypeProtobuf,
+			reader:     `syntax = "proto3"; message M { string a = 1; }`,
+			writer:     `syntax = "proto3"; message M { int32 a = 1; }`,
+			want:       []string{"field M.a type bytes doesn't match the writer's varint"},
+		},
+		{
+			name:       "protobuf message removed",
+			schemaType: sr.TypeProtobuf,
+			reader:     `syntax = "proto3"; message M { string a = 1; }`,
+			writer:     `syntax = "proto3"; message M { string a = 1; } message N { string b = 1; }`,
+			want:       []string{"message N is missing"},
+		},
+		{
+			name:       "protobuf field moved out of a oneof",
+			schemaType: sr.TypeProtobuf,
+			reader:     `syntax = "proto3"; message M { string a = 1; int32 b = 2; }`,
+			writer:     `syntax = "proto3"; message M { oneof choice { string a = 1; int32 b = 2; } }`,
+			want:       []string{"field M.a isn't in oneof choice", "field M.b isn't in oneof choice"},
+		},
+		{
+			name:       "protobuf field moved into a new oneof",
+			schemaType: sr.TypeProtobuf,
+			reader:     `syntax = "proto3"; message M { oneof choice { string a = 1; } }`,
+			writer:     `syntax = "proto3"; message M { string a = 1; }`,
+		},
+		{
+			name:       "protobuf fields moved into a oneof together",
+			schemaType: sr.TypeProtobuf,
+			reader:     `syntax = "proto3"; message M { oneof choice { string a = 1; int32 b = 2; } }`,
+			writer:     `syntax = "proto3"; message M { string a = 1; int32 b = 2; }`,
+			want:       []string{"2 fields are in oneof M.choice but not in the writer"},
+		},
+		{
+			name:       "protobuf optional field isn't a oneof",
+			schemaType: sr.TypeProtobuf,
+			reader:     `syntax = "proto3"; message M { optional string a = 1; }`,
+			writer:     `syntax = "proto3"; message M { string a = 1; }`,
+		},
+		{
+			name:       "json property added to an open content model",
+			schemaType: sr.TypeJSON,
+			reader:     `{"type":"object","properties":{"a":{"type":"string"},"b":{"type":"integer"}}}`,
+			writer:     `{"type":"object","properties":{"a":{"type":"string"}}}`,
+			want:       []string{"#: property b isn't in the writer's open content model"},
+		},
+		{
+			name:       "json property added to a closed content model",
+			schemaType: sr.TypeJSON,
+			reader:     `{"type":"object","properties":{"a":{"type":"string"},"b":{"type":"integer"}}}`,
+			writer:     `{"type":"object","properties":{"a":{"type":"string"}},"additionalProperties":false}`,
+		},
+		{
+			name:       "json property removed from a closed content model",
+			schemaType: sr.TypeJSON,
+			reader:     `{"type":"object","properties":{"a":{"type":"string"}},"additionalProperties":false}`,
+			writer:     `{"type":"object","properties":{"a":{"type":"string"},"b":{"type":"integer"}},"additionalProperties":false}`,
+			want:       []string{"#: property b isn't allowed by the closed content model"},
+		},
+		{
+			name:       "json content model closed",
+			schemaType: sr.TypeJSON,
+			reader:     `{"type":"object","properties":{"a":{"type":"string"}},"additionalProperties":false}`,
+			writer:     `{"type":"object","properties":{"a":{"type":"string"}}}`,
+			want:       []string{"#: additional properties aren't allowed"},
+		},
+		{
+			name:       "json property required",
+			schemaType: sr.TypeJSON,
+			reader:     `{"type":"object","properties":{"a":{"type":"string"}},"required":["a"]}`,
+			writer:     `{"type":"object","properties":{"a":{"type":"string"}}}`,
+			want:       []string{"#: property a is required but not by the writer"},
+		},
+		{
+			name:       "json property no longer required",
+			schemaType: sr.TypeJSON,
+			reader:     `{"type":"object","properties":{"a":{"type":"string"}}}`,
+			writer:     `{"type":"object","properties":{"a":{"type":"string"}},"required":["a"]}`,
+		},
+		{
+			name:       "json enum value added",
+			schemaType: sr.TypeJSON,
+			reader:     `{"enum":["a","b","c"]}`,
+			writer:     `{"enum":["a","b"]}`,
+		},
+		{
+			name:       "json enum value removed",
+			schemaType: sr.TypeJSON,
+			reader:     `{"enum":["a"]}`,
+			writer:     `{"enum":["a","b"]}`,
+			want:       []string{`#: enum value "b" is missing`},
+		},
+		{
+			name:       "json bounds widened",
+			schemaType: sr.TypeJSON,
+			reader:     `{"type":"string","minLength":1,"maxLength":20}`,
+			writer:     `{"type":"string","minLength":2,"maxLength":10}`,
+		},
+		{
+			name:       "json bounds narrowed",
+			schemaType: sr.TypeJSON,
+			reader:     `{"type":"number","minimum":5,"maximum":10}`,
+			writer:     `{"type":"number","minimum":0,"maximum":100}`,
+			want:       []string{"#: maximum 10 is narrower than the writer's", "#: minimum 5 is narrower than the writer's"},
+		},
+		{
+			name:       "json integer read as a number",
+			schemaType: sr.TypeJSON,
+			reader:     `{"type":"number"}`,
+			writer:     `{"type":"integer"}`,
+		},
+		{
+			name:       "json type narrowed in a nested property",
+			schemaType: sr.TypeJSON,
+			reader:     `{"type":"object","properties":{"a":{"type":"integer"}}}`,
+			writer:     `{"type":"object","properties":{"a":{"type":"number"}}}`,
+			want:       []string{"#/properties/a: doesn't allow type number"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser := newSchemaParser(&state.State{})
+			parse := func(subject string, schema string) any {
+				parsed, err := parser.parseForCompatibility(sr.SubjectSchema{Subject: subject, Version: 1, Schema: sr.Schema{Schema: schema, Type: tt.schemaType}})
+				if err != nil {
+					t.Fatal(err)
+				}
+				return parsed
+			}
+			problems := checkCompatible(tt.schemaType, parse("reader", tt.reader), parse("writer", tt.writer))
+			if len(problems) != len(tt.want) {
+				t.Fatalf("got %q, want %q", problems, tt.want)
+			}
+			for i, want := range tt.want {
+				if !strings.Contains(problems[i], want) {
+					t.Errorf("got %q, want it to contain %q", problems[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestCheckCompatibilityTransitive(t *testing.T) {
+	// Each version can read the one before it, but version 3 can't read
+	// version 1, which lacks a field that version 2 gave a default
+	newState := func(level sr.CompatibilityLevel) *state.State {
+		return &state.State{
+			SubjectSchemas: []sr.SubjectSchema{
+				{Subject: "a", Version: 1, ID: 1, Schema: sr.Schema{Schema: `{"type":"record","name":"R","fields":[]}`}},
+				{Subject: "a", Version: 2, ID: 2, Schema: sr.Schema{Schema: `{"type":"record","name":"R","fields":[{"name":"b","type":"int","default":0}]}`}},
+				{Subject: "a", Version: 3, ID: 3, Schema: sr.Schema{Schema: `{"type":"record","name":"R","fields":[{"name":"b","type":"int"}]}`}},
+			},
+			CompatibilityResults: []sr.CompatibilityResult{{Subject: "a", Level: level}},
+		}
+	}
+
+	tests := []struct {
+		level sr.CompatibilityLevel
+		want  []string
+	}{
+		{level: sr.CompatNone},
+		{level: sr.CompatBackward},
+		{level: sr.CompatBackwardTransitive, want: []string{"under BACKWARD_TRANSITIVE, can't read data written with version 1"}},
+		{level: sr.CompatForward},
+		{level: sr.CompatFullTransitive, want: []string{"under FULL_TRANSITIVE, can't read data written with version 1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.level.String(), func(t *testing.T) {
+			_, err := CheckCompatibilityProcess{}.Process(context.Background(), newState(tt.level))
+			problems := make([]validation.Problem, 0)
+			var report *validation.Report
+			if errors.As(err, &report) {
+				problems = report.Problems
+			} else if err != nil {
+				t.Fatal(err)
+			}
+			if len(problems) != len(tt.want) {
+				t.Fatalf("got %v, want %q", problems, tt.want)
+			}
+			for i, want := range tt.want {
+				if problems[i].Version != 3 || !strings.Contains(problems[i].Message, want) {
+					t.Errorf("got %v, want version 3 to contain %q", problems[i], want)
+				}
+			}
+		})
+	}
+}
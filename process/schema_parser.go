@@ -0,0 +1,183 @@
+package process
+
+import (
+	"context"
+	"fmt"
+	"github.com/bufbuild/protocompile"
+	"github.com/bufbuild/protocompile/linker"
+	"github.com/hamba/avro/v2"
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"github.com/twmb/franz-go/pkg/sr"
+	"go-schema-migrator/state"
+	"net/url"
+	"strings"
+)
+
+// schemaBase is where JSON schemas are found by the compiler, so that a
+// reference named "other.json" resolves against the schema that uses it
+const schemaBase = "mem://schemas/"
+
+// namedSchema is a referenced schema under the name it is imported by
+type namedSchema struct {
+	name          string
+	subjectSchema sr.SubjectSchema
+}
+
+// schemaParser parses the schemas in a state, resolving their references
+// against the other schemas in the same state
+type schemaParser struct {
+	schemas map[sr.SubjectVersion]sr.SubjectSchema
+}
+
+func newSchemaParser(s *state.State) schemaParser {
+	schemas := make(map[sr.SubjectVersion]sr.SubjectSchema)
+	for _, subjectSchema := range s.SubjectSchemas {
+		schemas[state.GetReference(subjectSchema)] = subjectSchema
+	}
+	return schemaParser{schemas: schemas}
+}
+
+// dependencies returns every schema that a schema references, directly or
+// indirectly, with each schema after those that it references
+func (p schemaParser) dependencies(subjectSchema sr.SubjectSchema) ([]namedSchema, error) {
+	result := make([]namedSchema, 0)
+	visited := make(map[sr.SubjectVersion]bool)
+	visiting := make(map[sr.SubjectVersion]bool)
+
+	var visit func(referrer sr.SubjectSchema) error
+	visit = func(referrer sr.SubjectSchema) error {
+		for _, schemaReference := range referrer.References {
+			ref := state.ResolveReference(referrer, schemaReference)
+			if visited[ref] {
+				continue
+			}
+			if visiting[ref] {
+				return fmt.Errorf("reference cycle through subject %v version %v", ref.Subject, ref.Version)
+			}
+			referenced, ok := p.schemas[ref]
+			if !ok {
+				return fmt.Errorf("reference %v to subject %v version %v isn't in the state", schemaReference.Name, ref.Subject, ref.Version)
+			}
+			visiting[ref] = true
+			if err := visit(referenced); err != nil {
+				return err
+			}
+			visiting[ref] = false
+			visited[ref] = true
+			result = append(result, namedSchema{name: schemaReference.Name, subjectSchema: referenced})
+		}
+		return nil
+	}
+
+	return result, visit(subjectSchema)
+}
+
+// parse checks that a schema parses as its type
+func (p schemaParser) parse(subjectSchema sr.SubjectSchema) error {
+	var err error
+	switch subjectSchema.Type {
+	case sr.TypeAvro:
+		_, err = p.parseAvro(subjectSchema)
+	case sr.TypeProtobuf:
+		_, err = p.parseProtobuf(subjectSchema)
+	case sr.TypeJSON:
+		_, err = p.parseJSON(subjectSchema)
+	default:
+		err = fmt.Errorf("unknown schema type %v", subjectSchema.Type)
+	}
+	return err
+}
+
+// parseAvro parses an Avro schema, having first parsed the schemas it
+// references so that the named types they define are known
+func (p schemaParser) parseAvro(subjectSchema sr.SubjectSchema) (avro.Schema, error) {
+	dependencies, err := p.dependencies(subjectSchema)
+	if err != nil {
+		return nil, err
+	}
+	cache := &avro.SchemaCache{}
+	for _, dependency := range dependencies {
+		_, err = avro.ParseWithCache(dependency.subjectSchema.Schema.Schema, "", cache)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse reference %v: %w", dependency.name, err)
+		}
+	}
+	return avro.ParseWithCache(subjectSchema.Schema.Schema, "", cache)
+}
+
+// parseProtobuf compiles a Protobuf schema, importing the schemas it
+// references by their reference names
+func (p schemaParser) parseProtobuf(subjectSchema sr.SubjectSchema) (linker.File, error) {
+	dependencies, err := p.dependencies(subjectSchema)
+	if err != nil {
+		return nil, err
+	}
+	files := make(map[string]string)
+	for _, dependency := range dependencies {
+		files[dependency.name] = dependency.subjectSchema.Schema.Schema
+	}
+	filename := "schema.proto"
+	for i := 1; files[filename] != ""; i++ {
+		filename = fmt.Sprintf("schema-%v.proto", i)
+	}
+	files[filename] = subjectSchema.Schema.Schema
+
+	compiler := protocompile.Compiler{
+		Resolver: protocompile.WithStandardImports(&protocompile.SourceResolver{
+			Accessor: protocompile.SourceAccessorFromMap(files),
+		}),
+	}
+	compiled, err := compiler.Compile(context.Background(), filename)
+	if err != nil {
+		return nil, err
+	}
+	return compiled[0], nil
+}
+
+// schemaURL returns where the JSON schema with the given reference name is found
+func schemaURL(name string) string {
+	if parsed, err := url.Parse(name); err == nil && parsed.IsAbs() {
+		return name
+	}
+	return schemaBase + strings.TrimPrefix(name, "/")
+}
+
+// parseJSON compiles a JSON schema, making the schemas it references
+// available by their reference names. Schemas without a $schema keyword are
+// compiled as draft 7, as in the registry.
+func (p schemaParser) parseJSON(subjectSchema sr.SubjectSchema) (*jsonschema.Schema, error) {
+	dependencies, err := p.dependencies(subjectSchema)
+	if err != nil {
+		return nil, err
+	}
+
+	compiler := jsonschema.NewCompiler()
+	compiler.DefaultDraft(jsonschema.Draft7)
+	// Nothing is loaded from outside the state
+	compiler.UseLoader(jsonschema.SchemeURLLoader{})
+
+	names := make(map[string]bool)
+	for _, dependency := range dependencies {
+		doc, err := jsonschema.UnmarshalJSON(strings.NewReader(dependency.subjectSchema.Schema.Schema))
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse reference %v: %w", dependency.name, err)
+		}
+		if err = compiler.AddResource(schemaURL(dependency.name), doc); err != nil {
+			return nil, fmt.Errorf("unable to add reference %v: %w", dependency.name, err)
+		}
+		names[schemaURL(dependency.name)] = true
+	}
+
+	doc, err := jsonschema.UnmarshalJSON(strings.NewReader(subjectSchema.Schema.Schema))
+	if err != nil {
+		return nil, err
+	}
+	location := schemaURL("schema.json")
+	for i := 1; names[location]; i++ {
+		location = schemaURL(fmt.Sprintf("schema-%v.json", i))
+	}
+	if err = compiler.AddResource(location, doc); err != nil {
+		return nil, err
+	}
+	return compiler.Compile(location)
+}
@@ -0,0 +1,147 @@
+package process
+
+import (
+	"context"
+	"fmt"
+	"github.com/twmb/franz-go/pkg/sr"
+	"go-schema-migrator/state"
+	"maps"
+	"regexp"
+	"slices"
+)
+
+// MetadataRule sets metadata on the schemas it selects. A schema is selected
+// if its subject matches any of Subjects, its type is one of Types, and its
+// ID is between MinID and MaxID inclusive, where each selector that is left
+// empty selects every schema. Mode is either "merge" (the default), which adds
+// to any metadata the schema already has, or "replace".
+type MetadataRule struct {
+	Subjects   []SubjectMatcher    `koanf:"subjects"`
+	Types      []string            `koanf:"types"`
+	MinID      int                 `koanf:"min_id"`
+	MaxID      int                 `koanf:"max_id"`
+	Mode       string              `koanf:"mode"`
+	Tags       map[string][]string `koanf:"tags"`
+	Properties map[string]string   `koanf:"properties"`
+	Sensitive  []string            `koanf:"sensitive"`
+}
+
+// metadataSelector is a compiled MetadataRule selection
+type metadataSelector struct {
+	subjects []*regexp.Regexp
+	types    []sr.SchemaType
+	minID    int
+	maxID    int
+}
+
+func (r MetadataRule) compile() (*metadataSelector, error) {
+	if r.Mode != "" && r.Mode != "merge" && r.Mode != "replace" {
+		return nil, fmt.Errorf("unknown mode: %v", r.Mode)
+	}
+	subjects, err := compileMatchers(r.Subjects)
+	if err != nil {
+		return nil, err
+	}
+	types := make([]sr.SchemaType, 0, len(r.Types))
+	for _, name := range r.Types {
+		var schemaType sr.SchemaType
+		err := schemaType.UnmarshalText([]byte(name))
+		if err != nil {
+			return nil, fmt.Errorf("unknown schema type: %v", name)
+		}
+		types = append(types, schemaType)
+	}
+	return &metadataSelector{subjects: subjects, types: types, minID: r.MinID, maxID: r.MaxID}, nil
+}
+
+func (s *metadataSelector) selects(subjectSchema sr.SubjectSchema) bool {
+	if len(s.subjects) > 0 && !matchesAny(s.subjects, subjectSchema.Subject) {
+		return false
+	}
+	if len(s.types) > 0 && !slices.Contains(s.types, subjectSchema.Type) {
+		return false
+	}
+	if s.minID > 0 && subjectSchema.ID < s.minID {
+		return false
+	}
+	if s.maxID > 0 && subjectSchema.ID > s.maxID {
+		return false
+	}
+	return true
+}
+
+// apply returns the metadata that results from applying the rule to existing
+func (r MetadataRule) apply(existing *sr.SchemaMetadata) *sr.SchemaMetadata {
+	var metadata sr.SchemaMetadata
+	if existing != nil && r.Mode != "replace" {
+		metadata.Tags = maps.Clone(existing.Tags)
+		metadata.Properties = maps.Clone(existing.Properties)
+		metadata.Sensitive = slices.Clone(existing.Sensitive)
+	}
+
+	for path, tags := range r.Tags {
+		if metadata.Tags == nil {
+			metadata.Tags = make(map[string][]string)
+		}
+		merged := slices.Clone(metadata.Tags[path])
+		for _, tag := range tags {
+			if !slices.Contains(merged, tag) {
+				merged = append(merged, tag)
+			}
+		}
+		metadata.Tags[path] = merged
+	}
+	for key, value := range r.Properties {
+		if metadata.Properties == nil {
+			metadata.Properties = make(map[string]string)
+		}
+		metadata.Properties[key] = value
+	}
+	for _, field := range r.Sensitive {
+		if !slices.Contains(metadata.Sensitive, field) {
+			metadata.Sensitive = append(metadata.Sensitive, field)
+		}
+	}
+
+	if len(metadata.Tags) == 0 && len(metadata.Properties) == 0 && len(metadata.Sensitive) == 0 {
+		return nil
+	}
+	return &metadata
+}
+
+// SetMetadataProcess applies its rules, in order, to the metadata of every
+// schema they select. Metadata belongs to the schema ID, so a rule that
+// selects one subject version applies to every version sharing its ID.
+type SetMetadataProcess struct {
+	Rules []MetadataRule `koanf:"rules"`
+}
+
+func (p SetMetadataProcess) Process(ctx context.Context, s *state.State) (*state.State, error) {
+	selectors := make([]*metadataSelector, 0, len(p.Rules))
+	for i, rule := range p.Rules {
+		selector, err := rule.compile()
+		if err != nil {
+			return nil, fmt.Errorf("unable to set metadata: rule %v: %w", i, err)
+		}
+		selectors = append(selectors, selector)
+	}
+
+	selected := make([]map[state.ContextID]bool, len(p.Rules))
+	for j := range p.Rules {
+		selected[j] = make(map[state.ContextID]bool)
+		for _, subjectSchema := range s.SubjectSchemas {
+			if selectors[j].selects(subjectSchema) {
+				selected[j][state.GetContextID(subjectSchema)] = true
+			}
+		}
+	}
+
+	for i := range s.SubjectSchemas {
+		for j, rule := range p.Rules {
+			if selected[j][state.GetContextID(s.SubjectSchemas[i])] {
+				s.SubjectSchemas[i].SchemaMetadata = rule.apply(s.SubjectSchemas[i].SchemaMetadata)
+			}
+		}
+	}
+	return s, nil
+}
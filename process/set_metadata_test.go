@@ -0,0 +1,39 @@
+package process
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/twmb/franz-go/pkg/sr"
+	"go-schema-migrator/state"
+)
+
+func TestSetMetadataSharedID(t *testing.T) {
+	s := &state.State{SubjectSchemas: []sr.SubjectSchema{
+		{Subject: "team-a-value", Version: 1, ID: 1, Schema: sr.Schema{Schema: `"string"`}},
+		{Subject: "team-b-value", Version: 1, ID: 1, Schema: sr.Schema{Schema: `"string"`}},
+		{Subject: "team-b-value", Version: 2, ID: 2, Schema: sr.Schema{Schema: `"int"`}},
+		{Subject: ":.other:team-b-value", Version: 1, ID: 1, Schema: sr.Schema{Schema: `"string"`}},
+	}}
+	p := SetMetadataProcess{Rules: []MetadataRule{{
+		Subjects:   []SubjectMatcher{{Glob: "team-a-*"}},
+		Properties: map[string]string{"owner": "team-a"},
+	}}}
+
+	s, err := p.Process(context.Background(), s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	owned := &sr.SchemaMetadata{Properties: map[string]string{"owner": "team-a"}}
+	want := []*sr.SchemaMetadata{owned, owned, nil, nil}
+	for i, subjectSchema := range s.SubjectSchemas {
+		if !reflect.DeepEqual(subjectSchema.SchemaMetadata, want[i]) {
+			t.Errorf("%v version %v: got %+v, want %+v", subjectSchema.Subject, subjectSchema.Version, subjectSchema.SchemaMetadata, want[i])
+		}
+	}
+	if report := s.Validate(); report.HasErrors() {
+		t.Errorf("unexpected errors: %v", report.Text())
+	}
+}
@@ -0,0 +1,96 @@
+package process
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// SubjectMatcher matches subjects by either a regular expression or a glob,
+// where a glob's * matches any run of characters and ? any single character
+type SubjectMatcher struct {
+	Regex string `koanf:"regex"`
+	Glob  string `koanf:"glob"`
+}
+
+func (m SubjectMatcher) compile() (*regexp.Regexp, error) {
+	if m.Regex != "" && m.Glob != "" {
+		return nil, fmt.Errorf("subject matcher has both a regex and a glob: %v, %v", m.Regex, m.Glob)
+	}
+	if m.Regex != "" {
+		re, err := regexp.Compile(m.Regex)
+		if err != nil {
+			return nil, fmt.Errorf("unable to compile regex %v: %w", m.Regex, err)
+		}
+		return re, nil
+	}
+	if m.Glob != "" {
+		return regexp.MustCompile(globToRegex(m.Glob)), nil
+	}
+	return nil, fmt.Errorf("subject matcher has neither a regex nor a glob")
+}
+
+// globToRegex converts a glob into an equivalent, anchored, regular expression
+func globToRegex(glob string) string {
+	var builder strings.Builder
+	builder.WriteString("^")
+	for _, r := range glob {
+		switch r {
+		case '*':
+			builder.WriteString(".*")
+		case '?':
+			builder.WriteString(".")
+		default:
+			builder.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	builder.WriteString("$")
+	return builder.String()
+}
+
+// subjectFilter selects the subjects that match any include rule (or every
+// subject if there are none), and don't match any exclude rule
+type subjectFilter struct {
+	include []*regexp.Regexp
+	exclude []*regexp.Regexp
+}
+
+func compileMatchers(matchers []SubjectMatcher) ([]*regexp.Regexp, error) {
+	result := make([]*regexp.Regexp, 0, len(matchers))
+	for _, matcher := range matchers {
+		re, err := matcher.compile()
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, re)
+	}
+	return result, nil
+}
+
+func newSubjectFilter(include []SubjectMatcher, exclude []SubjectMatcher) (*subjectFilter, error) {
+	includeRegexes, err := compileMatchers(include)
+	if err != nil {
+		return nil, fmt.Errorf("invalid include: %w", err)
+	}
+	excludeRegexes, err := compileMatchers(exclude)
+	if err != nil {
+		return nil, fmt.Errorf("invalid exclude: %w", err)
+	}
+	return &subjectFilter{include: includeRegexes, exclude: excludeRegexes}, nil
+}
+
+func matchesAny(regexes []*regexp.Regexp, subject string) bool {
+	for _, re := range regexes {
+		if re.MatchString(subject) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *subjectFilter) matches(subject string) bool {
+	if len(f.include) > 0 && !matchesAny(f.include, subject) {
+		return false
+	}
+	return !matchesAny(f.exclude, subject)
+}
@@ -0,0 +1,19 @@
+package process
+
+import (
+	"context"
+	"go-schema-migrator/state"
+	"go-schema-migrator/validation"
+)
+
+type ValidateMetadataProcess struct{}
+
+func (f ValidateMetadataProcess) Process(ctx context.Context, s *state.State) (*state.State, error) {
+	report := validation.NewReport()
+	for _, schema := range s.SubjectSchemas {
+		if schema.SchemaMetadata != nil {
+			report.Errorf("unexpected_metadata", schema.Subject, schema.Version, "schema has metadata")
+		}
+	}
+	return s, report.Err()
+}
@@ -0,0 +1,102 @@
+package process
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/cel-go/cel"
+	"github.com/twmb/franz-go/pkg/sr"
+	"go-schema-migrator/state"
+	"go-schema-migrator/validation"
+	"slices"
+	"strings"
+)
+
+var (
+	migrationRuleModes = []sr.SchemaRuleMode{sr.SchemaRuleModeUpgrade, sr.SchemaRuleModeDowngrade, sr.SchemaRuleModeUpdown}
+	domainRuleModes    = []sr.SchemaRuleMode{sr.SchemaRuleModeWrite, sr.SchemaRuleModeRead, sr.SchemaRuleModeWriteRead}
+)
+
+// ValidateRuleSetsProcess checks that every rule in every rule set is well
+// formed, and that the expressions of CEL rules parse
+type ValidateRuleSetsProcess struct{}
+
+// validateCEL reports any syntax errors in a CEL expression. As in the
+// registry's rule executors, an expression may be preceded by a guard
+// expression and a semicolon.
+func validateCEL(report *validation.Report, env *cel.Env, subject string, version int, rule sr.SchemaRule) {
+	expressions := []string{rule.Expr}
+	if guard, expr, found := strings.Cut(rule.Expr, ";"); found {
+		expressions = []string{expr}
+		if strings.TrimSpace(guard) != "" {
+			expressions = []string{guard, expr}
+		}
+	}
+	for _, expression := range expressions {
+		if strings.TrimSpace(expression) == "" {
+			report.Errorf("invalid_cel", subject, version, "rule %v has no expression", rule.Name)
+			continue
+		}
+		_, issues := env.Parse(expression)
+		if issues == nil || issues.Err() == nil {
+			continue
+		}
+		for _, issue := range issues.Errors() {
+			report.Errorf("invalid_cel", subject, version, "rule %v: line %v, column %v: %v", rule.Name, issue.Location.Line(), issue.Location.Column()+1, issue.Message)
+		}
+	}
+}
+
+func validateRuleSet(report *validation.Report, env *cel.Env, subject string, version int, ruleSet *sr.SchemaRuleSet) {
+	if ruleSet == nil {
+		return
+	}
+
+	names := make(map[string]bool)
+	check := func(rule sr.SchemaRule, kind string, modes []sr.SchemaRuleMode) {
+		if rule.Name == "" {
+			report.Errorf("invalid_rule", subject, version, "%v rule has no name", kind)
+		} else if names[rule.Name] {
+			report.Errorf("invalid_rule", subject, version, "rule name %v is used more than once", rule.Name)
+		}
+		names[rule.Name] = true
+
+		if !slices.Contains(modes, rule.Mode) {
+			report.Errorf("invalid_rule", subject, version, "rule %v has mode %v, which isn't valid for a %v rule", rule.Name, rule.Mode, kind)
+		}
+		if rule.Type == "" {
+			report.Errorf("invalid_rule", subject, version, "rule %v has no type", rule.Name)
+		}
+		switch strings.ToUpper(rule.Type) {
+		case "CEL", "CEL_FIELD":
+			validateCEL(report, env, subject, version, rule)
+		}
+	}
+
+	for _, rule := range ruleSet.MigrationRules {
+		check(rule, "migration", migrationRuleModes)
+	}
+	for _, rule := range ruleSet.DomainRules {
+		check(rule, "domain", domainRuleModes)
+	}
+}
+
+func (f ValidateRuleSetsProcess) Process(ctx context.Context, s *state.State) (*state.State, error) {
+	env, err := cel.NewEnv()
+	if err != nil {
+		return nil, fmt.Errorf("unable to create cel environment: %w", err)
+	}
+
+	report := validation.NewReport()
+	for _, subjectSchema := range s.SubjectSchemas {
+		validateRuleSet(report, env, subjectSchema.Subject, subjectSchema.Version, subjectSchema.SchemaRuleSet)
+	}
+	compatibilityResults := slices.Clone(s.CompatibilityResults)
+	if s.GlobalCompatibility != nil {
+		compatibilityResults = append(compatibilityResults, *s.GlobalCompatibility)
+	}
+	for _, result := range compatibilityResults {
+		validateRuleSet(report, env, result.Subject, 0, result.DefaultRuleSet)
+		validateRuleSet(report, env, result.Subject, 0, result.OverrideRuleSet)
+	}
+	return s, report.Err()
+}
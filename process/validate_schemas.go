@@ -0,0 +1,23 @@
+package process
+
+import (
+	"context"
+	"go-schema-migrator/state"
+	"go-schema-migrator/validation"
+)
+
+// ValidateSchemasProcess checks that every schema parses as its type, so that
+// a broken schema is found before the target registry rejects it
+type ValidateSchemasProcess struct{}
+
+func (f ValidateSchemasProcess) Process(ctx context.Context, s *state.State) (*state.State, error) {
+	parser := newSchemaParser(s)
+	report := validation.NewReport()
+	for _, subjectSchema := range s.SubjectSchemas {
+		err := parser.parse(subjectSchema)
+		if err != nil {
+			report.Errorf("invalid_schema", subjectSchema.Subject, subjectSchema.Version, "%v schema doesn't parse: %v", subjectSchema.Type, err)
+		}
+	}
+	return s, report.Err()
+}
@@ -0,0 +1,107 @@
+package registry
+
+import (
+	"fmt"
+	"github.com/knadh/koanf/v2"
+	"go-schema-migrator/process"
+	"go-schema-migrator/sink"
+	"go-schema-migrator/source"
+)
+
+// The sources, processes and sinks that come with the tool
+func init() {
+	RegisterSource("rest", func(conf *koanf.Koanf) (source.Source, error) {
+		s := source.RestSource{}
+		err := conf.Unmarshal("", &s)
+		if err != nil {
+			return nil, fmt.Errorf("unable to unmarshall rest source config: %w", err)
+		}
+		err = s.Connect()
+		if err != nil {
+			return nil, fmt.Errorf("unable to connect to rest source: %w", err)
+		}
+		return &s, nil
+	}, DescribeConfig("Reads every context of a registry through its REST API", source.RestSource{}))
+
+	RegisterSource("file", func(conf *koanf.Koanf) (source.Source, error) {
+		s := source.FileSource{}
+		err := conf.Unmarshal("", &s)
+		if err != nil {
+			return nil, fmt.Errorf("unable to unmarshall file source config: %w", err)
+		}
+		return &s, nil
+	}, DescribeConfig("Reads a YAML file written by the file sink", source.FileSource{}))
+
+	RegisterSource("v1file", func(conf *koanf.Koanf) (source.Source, error) {
+		s := source.FileSourceV1{}
+		err := conf.Unmarshal("", &s)
+		if err != nil {
+			return nil, fmt.Errorf("unable to unmarshall v1file source config: %w", err)
+		}
+		return &s, nil
+	}, DescribeConfig("Reads an export written by the previous Python tool", source.FileSourceV1{}))
+
+	RegisterSource("topic", func(conf *koanf.Koanf) (source.Source, error) {
+		s := source.TopicSource{}
+		err := conf.Unmarshal("", &s)
+		if err != nil {
+			return nil, fmt.Errorf("unable to unmarshall topic source config: %w", err)
+		}
+		return &s, nil
+	}, DescribeConfig("Reads a _schemas topic, with sasl and tls configured as for the brokers", source.TopicSource{}))
+
+	RegisterProcess("remove_metadata", UnmarshalProcess[process.RemoveMetadataProcess](), DescribeConfig("Removes the metadata from every schema", process.RemoveMetadataProcess{}))
+	RegisterProcess("add_metadata", UnmarshalProcess[process.AddMetadataProcess](), DescribeConfig("Adds test metadata to every schema", process.AddMetadataProcess{}))
+	RegisterProcess("validate_metadata", UnmarshalProcess[process.ValidateMetadataProcess](), DescribeConfig("Checks the metadata of every schema", process.ValidateMetadataProcess{}))
+	RegisterProcess("filter_subjects", UnmarshalProcess[process.FilterSubjectsProcess](), DescribeConfig("Keeps only the subjects that are included and not excluded", process.FilterSubjectsProcess{}))
+	RegisterProcess("rename_subjects", UnmarshalProcess[process.RenameSubjectsProcess](), DescribeConfig("Renames subjects by applying every rule in order", process.RenameSubjectsProcess{}))
+	RegisterProcess("remap_ids", UnmarshalProcess[process.RemapIDsProcess](), DescribeConfig("Gives schemas new IDs by offset, mapping file or the next free ID in a target", process.RemapIDsProcess{}))
+	RegisterProcess("renumber_versions", UnmarshalProcess[process.RenumberVersionsProcess](), DescribeConfig("Numbers the versions of each subject from 1 without gaps", process.RenumberVersionsProcess{}))
+	RegisterProcess("drop_soft_deleted", UnmarshalProcess[process.DropSoftDeletedProcess](), DescribeConfig("Removes soft deleted subject versions", process.DropSoftDeletedProcess{}))
+	RegisterProcess("set_metadata", UnmarshalProcess[process.SetMetadataProcess](), DescribeConfig("Sets tags, properties and sensitive fields on the schemas each rule selects", process.SetMetadataProcess{}))
+	RegisterProcess("remove_rulesets", UnmarshalProcess[process.RemoveRuleSetsProcess](), DescribeConfig("Removes every rule set", process.RemoveRuleSetsProcess{}))
+	RegisterProcess("validate_rulesets", UnmarshalProcess[process.ValidateRuleSetsProcess](), DescribeConfig("Checks that every rule is well formed and its CEL expressions parse", process.ValidateRuleSetsProcess{}))
+	RegisterProcess("validate_schemas", UnmarshalProcess[process.ValidateSchemasProcess](), DescribeConfig("Checks that every schema parses as its type", process.ValidateSchemasProcess{}))
+	RegisterProcess("check_compatibility", UnmarshalProcess[process.CheckCompatibilityProcess](), DescribeConfig("Checks each subject version against the earlier versions under its compatibility level", process.CheckCompatibilityProcess{}))
+	RegisterProcess("normalize_schemas", UnmarshalProcess[process.NormalizeSchemasProcess](), DescribeConfig("Rewrites every schema into canonical form", process.NormalizeSchemasProcess{}))
+	RegisterProcess("dedupe_schemas", UnmarshalProcess[process.DedupeSchemasProcess](), DescribeConfig("Finds schemas registered under more than one ID, and optionally collapses them", process.DedupeSchemasProcess{}))
+
+	RegisterSink("rest", func(conf *koanf.Koanf) (sink.Sink, error) {
+		s := sink.RestSink{}
+		err := conf.Unmarshal("", &s)
+		if err != nil {
+			return nil, fmt.Errorf("unable to unmarshall rest sink config: %w", err)
+		}
+		err = s.Connect()
+		if err != nil {
+			return nil, fmt.Errorf("unable to connect to rest sink: %w", err)
+		}
+		return &s, nil
+	}, DescribeConfig("Registers schemas through a registry's REST API in IMPORT mode", sink.RestSink{}))
+
+	RegisterSink("file", func(conf *koanf.Koanf) (sink.Sink, error) {
+		s := sink.FileSink{}
+		err := conf.Unmarshal("", &s)
+		if err != nil {
+			return nil, fmt.Errorf("unable to unmarshall file sink config: %w", err)
+		}
+		return &s, nil
+	}, DescribeConfig("Writes the state to a YAML file", sink.FileSink{}))
+
+	RegisterSink("debug", func(conf *koanf.Koanf) (sink.Sink, error) {
+		return &sink.DebugSink{}, nil
+	}, DescribeConfig("Writes the state to the console", sink.DebugSink{}))
+
+	RegisterSink("topic", func(conf *koanf.Koanf) (sink.Sink, error) {
+		s := sink.TopicSink{}
+		err := conf.Unmarshal("", &s)
+		if err != nil {
+			return nil, fmt.Errorf("unable to unmarshall topic sink config: %w", err)
+		}
+		err = s.Connect()
+		if err != nil {
+			return nil, fmt.Errorf("unable to connect to topic sink: %w", err)
+		}
+		return &s, nil
+	}, DescribeConfig("Writes records directly to a _schemas topic, with sasl and tls configured as for the brokers", sink.TopicSink{}))
+}
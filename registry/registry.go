@@ -0,0 +1,205 @@
+// Package registry builds the sources, processes and sinks named in a
+// configuration, and is where a program embedding the migrator adds its own
+package registry
+
+import (
+	"fmt"
+	"github.com/knadh/koanf/v2"
+	"go-schema-migrator/process"
+	"go-schema-migrator/sink"
+	"go-schema-migrator/source"
+	"io"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// ProcessFactory builds a process from its configuration
+type ProcessFactory func(conf *koanf.Koanf) (process.Process, error)
+
+// SourceFactory builds a source from its configuration
+type SourceFactory func(conf *koanf.Koanf) (source.Source, error)
+
+// SinkFactory builds a sink from its configuration
+type SinkFactory func(conf *koanf.Koanf) (sink.Sink, error)
+
+// ConfigField is a single configuration key that a plugin takes
+type ConfigField struct {
+	Key  string
+	Type string
+}
+
+// ConfigDescription describes what a plugin does and the configuration it takes
+type ConfigDescription struct {
+	Summary string
+	Fields  []ConfigField
+}
+
+type registration[F any] struct {
+	factory     F
+	description ConfigDescription
+}
+
+var (
+	registeredProcesses = make(map[string]registration[ProcessFactory])
+	registeredSources   = make(map[string]registration[SourceFactory])
+	registeredSinks     = make(map[string]registration[SinkFactory])
+)
+
+func register[F any](kind string, registry map[string]registration[F], name string, factory F, description ConfigDescription) {
+	if _, ok := registry[name]; ok {
+		panic(fmt.Errorf("%v %v is already registered", kind, name))
+	}
+	registry[name] = registration[F]{factory: factory, description: description}
+}
+
+// RegisterProcess makes a process available to the processes section of the
+// configuration under name. It is meant to be called from an init function,
+// and panics if the name is already taken.
+func RegisterProcess(name string, factory ProcessFactory, description ConfigDescription) {
+	register("process", registeredProcesses, name, factory, description)
+}
+
+// RegisterSource makes a source available under name, as RegisterProcess does
+func RegisterSource(name string, factory SourceFactory, description ConfigDescription) {
+	register("source", registeredSources, name, factory, description)
+}
+
+// RegisterSink makes a sink available under name, as RegisterProcess does
+func RegisterSink(name string, factory SinkFactory, description ConfigDescription) {
+	register("sink", registeredSinks, name, factory, description)
+}
+
+// UnmarshalProcess returns a factory for a process that is configured by
+// unmarshalling its configuration into a new T
+func UnmarshalProcess[T any, P interface {
+	*T
+	process.Process
+}]() ProcessFactory {
+	return func(conf *koanf.Koanf) (process.Process, error) {
+		process := P(new(T))
+		err := conf.Unmarshal("", process)
+		if err != nil {
+			return nil, err
+		}
+		return process, nil
+	}
+}
+
+// DescribeConfig describes a plugin whose configuration is unmarshalled into
+// config, listing the key and type of each of its fields
+func DescribeConfig(summary string, config any) ConfigDescription {
+	return ConfigDescription{Summary: summary, Fields: describeFields("", reflect.TypeOf(config))}
+}
+
+// hasKoanfTags reports whether a struct is one of ours, configured through
+// koanf tags, rather than one whose fields are internal
+func hasKoanfTags(t reflect.Type) bool {
+	for i := 0; i < t.NumField(); i++ {
+		if _, ok := t.Field(i).Tag.Lookup("koanf"); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func describeFields(prefix string, t reflect.Type) []ConfigField {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	fields := make([]ConfigField, 0)
+	if t.Kind() != reflect.Struct {
+		return fields
+	}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		switch field.Type.Kind() {
+		case reflect.Interface, reflect.Func, reflect.Chan:
+			continue
+		}
+		tag := field.Tag.Get("koanf")
+		name, options, _ := strings.Cut(tag, ",")
+		if name == "-" {
+			continue
+		}
+		if options == "squash" {
+			fields = append(fields, describeFields(prefix, field.Type)...)
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		key := prefix + name
+
+		fieldType := field.Type
+		for fieldType.Kind() == reflect.Pointer {
+			fieldType = fieldType.Elem()
+		}
+		if fieldType.Kind() == reflect.Struct && hasKoanfTags(fieldType) {
+			fields = append(fields, describeFields(key+".", fieldType)...)
+			continue
+		}
+		if fieldType.Kind() == reflect.Slice {
+			element := fieldType.Elem()
+			for element.Kind() == reflect.Pointer {
+				element = element.Elem()
+			}
+			if element.Kind() == reflect.Struct && hasKoanfTags(element) {
+				fields = append(fields, describeFields(key+"[].", element)...)
+				continue
+			}
+		}
+		fields = append(fields, ConfigField{Key: key, Type: fieldType.String()})
+	}
+	return fields
+}
+
+func NewProcess(name string, conf *koanf.Koanf) (process.Process, error) {
+	registered, ok := registeredProcesses[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown process type: %v", name)
+	}
+	return registered.factory(conf)
+}
+
+func NewSource(name string, conf *koanf.Koanf) (source.Source, error) {
+	registered, ok := registeredSources[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown source type: %v", name)
+	}
+	return registered.factory(conf)
+}
+
+func NewSink(name string, conf *koanf.Koanf) (sink.Sink, error) {
+	registered, ok := registeredSinks[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown sink type: %v", name)
+	}
+	return registered.factory(conf)
+}
+
+func writeDescriptions[F any](w io.Writer, title string, registry map[string]registration[F]) {
+	fmt.Fprintf(w, "%v:\n", title)
+	for _, name := range slices.Sorted(maps.Keys(registry)) {
+		description := registry[name].description
+		fmt.Fprintf(w, "  %v\n", name)
+		if description.Summary != "" {
+			fmt.Fprintf(w, "      %v\n", description.Summary)
+		}
+		for _, field := range description.Fields {
+			fmt.Fprintf(w, "      %-32v %v\n", field.Key, field.Type)
+		}
+	}
+}
+
+// List writes out every registered source, process and sink, with
+// the configuration that each takes
+func List(w io.Writer) {
+	writeDescriptions(w, "Sources", registeredSources)
+	writeDescriptions(w, "Processes", registeredProcesses)
+	writeDescriptions(w, "Sinks", registeredSinks)
+}
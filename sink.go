@@ -1,5 +0,0 @@
-package main
-
-type Sink interface {
-	PutState(*State) error
-}
@@ -0,0 +1,77 @@
+package sink
+
+import (
+	"fmt"
+	"github.com/twmb/franz-go/pkg/sr"
+	"go-schema-migrator/state"
+)
+
+// ContextMapping moves the subjects in one context into another
+type ContextMapping struct {
+	From string `koanf:"from"`
+	To   string `koanf:"to"`
+}
+
+// ContextConfig chooses the contexts that a sink writes into. Context is
+// where the default context is written, and ContextMapping moves any other
+// context. Contexts that aren't mapped are written as they are.
+type ContextConfig struct {
+	Context        string           `koanf:"context"`
+	ContextMapping []ContextMapping `koanf:"context_mapping"`
+}
+
+// apply returns the state with its subjects moved into their target contexts
+func (c ContextConfig) apply(s *state.State) (*state.State, error) {
+	mapping := make(map[string]string)
+	if c.Context != "" {
+		mapping[state.DefaultContext] = state.NormalizeContext(c.Context)
+	}
+	for _, contextMapping := range c.ContextMapping {
+		mapping[state.NormalizeContext(contextMapping.From)] = state.NormalizeContext(contextMapping.To)
+	}
+	if len(mapping) == 0 {
+		return s, nil
+	}
+
+	// Two source contexts merged into one target must not share a subject
+	targets := make(map[string]string)
+	for _, subject := range s.Subjects() {
+		context, name := state.SplitContext(subject)
+		target, ok := mapping[context]
+		if !ok {
+			target = context
+		}
+		mapped := state.Qualify(target, name)
+		if existing, ok := targets[mapped]; ok && existing != subject {
+			return nil, fmt.Errorf("subjects %v and %v would both be written to %v", existing, subject, mapped)
+		}
+		targets[mapped] = subject
+	}
+
+	result := s.MapSubjects(func(subject string) string {
+		context, name := state.SplitContext(subject)
+		target, ok := mapping[context]
+		if !ok {
+			return subject
+		}
+		return state.Qualify(target, name)
+	})
+
+	// The global configuration of the source becomes the configuration of the
+	// context that its default context is written into
+	if target, ok := mapping[state.DefaultContext]; ok && target != state.DefaultContext {
+		subject := state.Qualify(target, "")
+		if result.GlobalCompatibility != nil {
+			compatibilityResult := *result.GlobalCompatibility
+			compatibilityResult.Subject = subject
+			result.CompatibilityResults = append(result.CompatibilityResults, compatibilityResult)
+			result.GlobalCompatibility = nil
+		}
+		if result.GlobalMode != nil {
+			result.Modes = append(result.Modes, sr.ModeResult{Subject: subject, Mode: *result.GlobalMode})
+			result.GlobalMode = nil
+		}
+	}
+
+	return result, nil
+}
@@ -1,14 +1,16 @@
-package main
+package sink
 
 import (
+	"context"
 	"fmt"
+	"go-schema-migrator/state"
 	"gopkg.in/yaml.v3"
 )
 
 type DebugSink struct {
 }
 
-func (r *DebugSink) PutState(state *State) error {
+func (r *DebugSink) PutState(ctx context.Context, state *state.State) error {
 
 	data, err := yaml.Marshal(state)
 	if err != nil {
@@ -1,7 +1,9 @@
-package main
+package sink
 
 import (
+	"context"
 	"fmt"
+	"go-schema-migrator/state"
 	"gopkg.in/yaml.v3"
 	"os"
 )
@@ -10,7 +12,7 @@ type FileSink struct {
 	Filename string
 }
 
-func (f *FileSink) PutState(state *State) error {
+func (f *FileSink) PutState(ctx context.Context, state *state.State) error {
 
 	data, err := yaml.Marshal(state)
 	if err != nil {
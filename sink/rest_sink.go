@@ -0,0 +1,330 @@
+package sink
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"github.com/twmb/franz-go/pkg/sr"
+	"go-schema-migrator/client"
+	"go-schema-migrator/internal/collections"
+	"go-schema-migrator/state"
+	"go-schema-migrator/validation"
+)
+
+type RestSink struct {
+	URL           string             `koanf:"url"`
+	Username      string             `koanf:"username"`
+	Password      string             `koanf:"password"`
+	TLS           *tls.Config        `koanf:"tls"`
+	ImportMode    string             `koanf:"import_mode"`
+	Force         bool               `koanf:"force"`
+	Compatibility string             `koanf:"compatibility"`
+	Retry         client.RetryConfig `koanf:"retry"`
+	Target        string             `koanf:"target"`
+
+	ContextConfig `koanf:",squash"`
+
+	client  *sr.Client
+	profile targetProfile
+	report  *validation.Report
+}
+
+func (r *RestSink) Connect() error {
+	opts := make([]sr.ClientOpt, 0)
+	opts = append(opts, sr.URLs(r.URL))
+	opts = append(opts, sr.BasicAuth(r.Username, r.Password))
+	opts = append(opts, sr.DialTLSConfig(r.TLS))
+	client, err := sr.NewClient(opts...)
+	if err != nil {
+		return fmt.Errorf("unable to create schema registry client: %w", err)
+	}
+	if r.ImportMode == "" {
+		r.ImportMode = "registry"
+	}
+	if r.ImportMode != "registry" && r.ImportMode != "subject" {
+		return fmt.Errorf("unknown import mode: %v", r.ImportMode)
+	}
+	profile, err := getTargetProfile(r.Target)
+	if err != nil {
+		return err
+	}
+	r.client = client
+	r.profile = profile
+	r.report = validation.NewReport()
+	return nil
+}
+
+// Report returns what was lost in writing to the target
+func (r *RestSink) Report() *validation.Report {
+	return r.report
+}
+
+// getMode returns the mode of the registry, or of the subject if one is given
+func (r *RestSink) getMode(ctx context.Context, subject ...string) (sr.ModeResult, error) {
+	return client.Retry(ctx, r.Retry, func(ctx context.Context) (sr.ModeResult, error) {
+		result := r.client.Mode(ctx, subject...)[0]
+		return result, result.Err
+	})
+}
+
+// setMode sets the mode of the registry, or of each subject if any are given
+func (r *RestSink) setMode(ctx context.Context, mode sr.Mode, subjects ...string) error {
+	_, err := client.Retry(ctx, r.Retry, func(ctx context.Context) ([]sr.ModeResult, error) {
+		results := r.client.SetMode(ctx, mode, subjects...)
+		return results, client.ModeErrors(results)
+	})
+	return err
+}
+
+func (r *RestSink) resetMode(ctx context.Context, subjects ...string) error {
+	_, err := client.Retry(ctx, r.Retry, func(ctx context.Context) ([]sr.ModeResult, error) {
+		results := r.client.ResetMode(ctx, subjects...)
+		return results, client.ModeErrors(results)
+	})
+	return err
+}
+
+// toSetCompatibility returns the parts of a compatibility result that the
+// target supports
+func (r *RestSink) toSetCompatibility(result sr.CompatibilityResult) sr.SetCompatibility {
+	result = r.profile.filterCompatibility(r.report, result)
+	return sr.SetCompatibility{
+		Level:            result.Level,
+		Alias:            result.Alias,
+		Normalize:        result.Normalize,
+		Group:            result.Group,
+		DefaultMetadata:  result.DefaultMetadata,
+		OverrideMetadata: result.OverrideMetadata,
+		DefaultRuleSet:   result.DefaultRuleSet,
+		OverrideRuleSet:  result.OverrideRuleSet,
+	}
+}
+
+// setCompatibility sets the compatibility of the registry, or of the subject
+// if one is given
+func (r *RestSink) setCompatibility(ctx context.Context, compatibility sr.SetCompatibility, subject ...string) error {
+	_, err := client.Retry(ctx, r.Retry, func(ctx context.Context) (sr.CompatibilityResult, error) {
+		result := r.client.SetCompatibility(ctx, compatibility, subject...)[0]
+		return result, result.Err
+	})
+	return err
+}
+
+// enterImportMode switches the registry (or each subject) into IMPORT mode, and
+// returns a function that puts back whatever mode was there before. The mode
+// is put back even if ctx has since been cancelled.
+func (r *RestSink) enterImportMode(ctx context.Context, subjects []string) (func() error, error) {
+	setCtx := ctx
+	if r.Force {
+		setCtx = sr.WithParams(ctx, sr.Force)
+	}
+	restoreCtx := context.WithoutCancel(setCtx)
+
+	if r.ImportMode == "registry" {
+		previous, err := r.getMode(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("unable to retrieve registry mode: %w", err)
+		}
+		if err := r.setMode(setCtx, sr.ModeImport); err != nil {
+			return nil, fmt.Errorf("unable to set registry into import mode: %w", err)
+		}
+		return func() error {
+			return r.setMode(restoreCtx, previous.Mode)
+		}, nil
+	}
+
+	// Subjects that don't yet exist have no mode of their own to put back
+	previous := make(map[sr.Mode][]string)
+	reset := make([]string, 0)
+	for _, subject := range subjects {
+		result, err := r.getMode(ctx, subject)
+		if err != nil {
+			if !client.IsNotFound(err) {
+				return nil, fmt.Errorf("unable to retrieve mode for subject %q: %w", subject, err)
+			}
+			reset = append(reset, subject)
+			continue
+		}
+		previous[result.Mode] = append(previous[result.Mode], subject)
+	}
+	if err := r.setMode(setCtx, sr.ModeImport, subjects...); err != nil {
+		return nil, fmt.Errorf("unable to set subjects into import mode: %w", err)
+	}
+	return func() error {
+		errs := make([]error, 0)
+		for mode, modeSubjects := range previous {
+			errs = append(errs, r.setMode(restoreCtx, mode, modeSubjects...))
+		}
+		if len(reset) > 0 {
+			errs = append(errs, r.resetMode(restoreCtx, reset...))
+		}
+		return errors.Join(errs...)
+	}, nil
+}
+
+func (r *RestSink) putState(ctx context.Context, state *state.State) error {
+	// Write subject versions, in state order so that references already exist
+	for _, subjectSchema := range state.SubjectSchemas {
+		subjectSchema = r.profile.filterSchema(r.report, subjectSchema)
+		_, err := client.Retry(ctx, r.Retry, func(ctx context.Context) (sr.SubjectSchema, error) {
+			return r.client.CreateSchemaWithIDAndVersion(ctx, subjectSchema.Subject, subjectSchema.Schema, subjectSchema.ID, subjectSchema.Version)
+		})
+		if err != nil {
+			return fmt.Errorf("unable to register subject %v version %v: %w", subjectSchema.Subject, subjectSchema.Version, err)
+		}
+	}
+
+	// Write soft deletions, deleting whole subjects at once
+	deletedSubjects := collections.ToMap(state.DeletedSubjects)
+	for _, deletion := range state.SoftDeletions {
+		if deletedSubjects[deletion.Subject] {
+			continue
+		}
+		_, err := client.Retry(ctx, r.Retry, func(ctx context.Context) (any, error) {
+			return nil, r.client.DeleteSchema(ctx, deletion.Subject, deletion.Version, sr.SoftDelete)
+		})
+		if err != nil {
+			return fmt.Errorf("unable to delete subject %v version %v: %w", deletion.Subject, deletion.Version, err)
+		}
+	}
+
+	for _, subject := range state.DeletedSubjects {
+		_, err := client.Retry(ctx, r.Retry, func(ctx context.Context) ([]int, error) {
+			return r.client.DeleteSubject(ctx, subject, sr.SoftDelete)
+		})
+		if err != nil {
+			return fmt.Errorf("unable to delete subject %v: %w", subject, err)
+		}
+	}
+
+	// Write hard deletions and reserved IDs, registering a placeholder under
+	// the ID so that the registry won't hand it out again
+	for _, deletion := range r.replays(state) {
+		_, err := client.Retry(ctx, r.Retry, func(ctx context.Context) (sr.SubjectSchema, error) {
+			return r.client.CreateSchemaWithIDAndVersion(ctx, deletion.Subject, deletion.PlaceholderSchema(), deletion.ID, deletion.Version)
+		})
+		if err != nil {
+			return fmt.Errorf("unable to register placeholder for subject %v version %v: %w", deletion.Subject, deletion.Version, err)
+		}
+		for _, how := range []sr.DeleteHow{sr.SoftDelete, sr.HardDelete} {
+			_, err = client.Retry(ctx, r.Retry, func(ctx context.Context) (any, error) {
+				return nil, r.client.DeleteSchema(ctx, deletion.Subject, deletion.Version, how)
+			})
+			if err != nil {
+				return fmt.Errorf("unable to hard delete subject %v version %v: %w", deletion.Subject, deletion.Version, err)
+			}
+		}
+	}
+
+	return r.putCompatibilities(ctx, state)
+}
+
+// replays returns the hard deletions and reserved IDs that need a placeholder
+// registering in the target
+func (r *RestSink) replays(state *state.State) []state.HardDeletion {
+	return append(state.HardDeletionsToReplay(), state.ReservedIDsToReplay(r.ContextConfig.Context)...)
+}
+
+// putCompatibilities sets each subject's compatibility and then the global
+// compatibility
+func (r *RestSink) putCompatibilities(ctx context.Context, state *state.State) error {
+	errs := make([]error, 0)
+	for _, result := range state.CompatibilityResults {
+		err := r.setCompatibility(ctx, r.toSetCompatibility(result), result.Subject)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("unable to set compatibility for subject %q: %w", result.Subject, err))
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	// Write closing compatibility level, configured on the sink or else taken from the source
+	if r.Compatibility != "" {
+		var level sr.CompatibilityLevel
+		err := level.UnmarshalText([]byte(r.Compatibility))
+		if err != nil {
+			return fmt.Errorf("unable to unmarshall compatibility level: %w", err)
+		}
+		err = r.setCompatibility(ctx, sr.SetCompatibility{Level: level})
+		if err != nil {
+			return fmt.Errorf("unable to set global compatibility: %w", err)
+		}
+	} else if state.GlobalCompatibility != nil {
+		err := r.setCompatibility(ctx, r.toSetCompatibility(*state.GlobalCompatibility))
+		if err != nil {
+			return fmt.Errorf("unable to set global compatibility: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (r *RestSink) PutState(ctx context.Context, state *state.State) error {
+	state, err := r.ContextConfig.apply(state)
+	if err != nil {
+		return fmt.Errorf("unable to map contexts: %w", err)
+	}
+
+	// Placeholders are registered too, so their subjects need IMPORT mode
+	subjects := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, subjectSchema := range state.SubjectSchemas {
+		if !seen[subjectSchema.Subject] {
+			seen[subjectSchema.Subject] = true
+			subjects = append(subjects, subjectSchema.Subject)
+		}
+	}
+	for _, deletion := range r.replays(state) {
+		if !seen[deletion.Subject] {
+			seen[deletion.Subject] = true
+			subjects = append(subjects, deletion.Subject)
+		}
+	}
+
+	if len(subjects) == 0 {
+		// With nothing to register, the target needn't go into IMPORT mode
+		err = r.putCompatibilities(ctx, state)
+	} else {
+		var restore func() error
+		restore, err = r.enterImportMode(ctx, subjects)
+		if err != nil {
+			return err
+		}
+
+		err = r.putState(ctx, state)
+
+		// Always attempt to put the previous mode back, even if the import failed
+		if restoreErr := restore(); restoreErr != nil {
+			err = errors.Join(err, fmt.Errorf("unable to restore previous mode: %w", restoreErr))
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	// Finally, bring the modes into line with the source
+	return r.putModes(ctx, state)
+}
+
+// putModes sets each subject's mode and then the global mode to match the state
+func (r *RestSink) putModes(ctx context.Context, state *state.State) error {
+	if r.Force {
+		ctx = sr.WithParams(ctx, sr.Force)
+	}
+
+	for _, result := range state.Modes {
+		err := r.setMode(ctx, result.Mode, result.Subject)
+		if err != nil {
+			return fmt.Errorf("unable to set mode for subject %q: %w", result.Subject, err)
+		}
+	}
+	if state.GlobalMode != nil {
+		err := r.setMode(ctx, *state.GlobalMode)
+		if err != nil {
+			return fmt.Errorf("unable to set global mode: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,224 @@
+package sink
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/twmb/franz-go/pkg/sr"
+	"go-schema-migrator/state"
+)
+
+// fakeRegistry serves just enough of the registry API for the REST sink,
+// recording each change made to it
+type fakeRegistry struct {
+	mu       sync.Mutex
+	modes    map[string]string
+	schemas  map[sr.SubjectVersion]sr.SubjectSchema
+	fail     string
+	requests []string
+}
+
+func newFakeRegistry(t *testing.T, modes map[string]string) (*fakeRegistry, string) {
+	f := &fakeRegistry{modes: modes, schemas: make(map[sr.SubjectVersion]sr.SubjectSchema)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /mode", f.getMode)
+	mux.HandleFunc("GET /mode/{subject}", f.getMode)
+	mux.HandleFunc("PUT /mode", f.setMode)
+	mux.HandleFunc("PUT /mode/{subject}", f.setMode)
+	mux.HandleFunc("DELETE /mode/{subject}", f.resetMode)
+	mux.HandleFunc("POST /subjects/{subject}/versions", f.register)
+	mux.HandleFunc("GET /schemas/ids/{id}/versions", f.usages)
+	mux.HandleFunc("GET /subjects/{subject}/versions/{version}", f.getSchema)
+	mux.HandleFunc("DELETE /subjects/{subject}/versions/{version}", f.deleteSchema)
+	mux.HandleFunc("PUT /config", f.setConfig)
+	mux.HandleFunc("PUT /config/{subject}", f.setConfig)
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return f, server.URL
+}
+
+func (f *fakeRegistry) record(format string, args ...any) {
+	f.requests = append(f.requests, fmt.Sprintf(format, args...))
+}
+
+func writeJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(value)
+}
+
+func (f *fakeRegistry) getMode(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	mode, ok := f.modes[r.PathValue("subject")]
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]any{"error_code": 40409, "message": "subject not found"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"mode": mode})
+}
+
+func (f *fakeRegistry) setMode(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var body struct {
+		Mode string `json:"mode"`
+	}
+	_ = json.NewDecoder(r.Body).Decode(&body)
+	f.modes[r.PathValue("subject")] = body.Mode
+	if r.PathValue("subject") == "" {
+		f.record("global mode %v", body.Mode)
+	} else {
+		f.record("mode %v %v", r.PathValue("subject"), body.Mode)
+	}
+	writeJSON(w, http.StatusOK, body)
+}
+
+func (f *fakeRegistry) resetMode(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	mode := f.modes[r.PathValue("subject")]
+	delete(f.modes, r.PathValue("subject"))
+	f.record("reset mode %v", r.PathValue("subject"))
+	writeJSON(w, http.StatusOK, map[string]string{"mode": mode})
+}
+
+func (f *fakeRegistry) register(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var subjectSchema sr.SubjectSchema
+	_ = json.NewDecoder(r.Body).Decode(&subjectSchema)
+	subjectSchema.Subject = r.PathValue("subject")
+	f.record("register %v/%v", subjectSchema.Subject, subjectSchema.Version)
+	if subjectSchema.Subject == f.fail {
+		writeJSON(w, http.StatusUnprocessableEntity, map[string]any{"error_code": 42201, "message": "invalid schema"})
+		return
+	}
+	f.schemas[state.GetReference(subjectSchema)] = subjectSchema
+	writeJSON(w, http.StatusOK, map[string]int{"id": subjectSchema.ID})
+}
+
+func (f *fakeRegistry) usages(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	id, _ := strconv.Atoi(r.PathValue("id"))
+	usages := make([]sr.SubjectVersion, 0)
+	for reference, subjectSchema := range f.schemas {
+		if subjectSchema.ID == id {
+			usages = append(usages, reference)
+		}
+	}
+	writeJSON(w, http.StatusOK, usages)
+}
+
+func (f *fakeRegistry) getSchema(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	version, _ := strconv.Atoi(r.PathValue("version"))
+	writeJSON(w, http.StatusOK, f.schemas[sr.SubjectVersion{Subject: r.PathValue("subject"), Version: version}])
+}
+
+func (f *fakeRegistry) deleteSchema(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	how := "soft"
+	if r.URL.Query().Get("permanent") == "true" {
+		how = "hard"
+	}
+	f.record("%v delete %v/%v", how, r.PathValue("subject"), r.PathValue("version"))
+	writeJSON(w, http.StatusOK, 1)
+}
+
+func (f *fakeRegistry) setConfig(w http.ResponseWriter, r *http.Request) {
+	var body map[string]any
+	_ = json.NewDecoder(r.Body).Decode(&body)
+	writeJSON(w, http.StatusOK, body)
+}
+
+func TestRestSinkPutState(t *testing.T) {
+	tests := []struct {
+		name       string
+		importMode string
+		modes      map[string]string
+		fail       string
+		state      *state.State
+		want       []string
+		wantModes  map[string]string
+		wantErr    bool
+	}{
+		{
+			name:  "the previous mode is restored when a registration fails",
+			modes: map[string]string{"": "READONLY"},
+			fail:  "b",
+			state: &state.State{SubjectSchemas: []sr.SubjectSchema{
+				{Subject: "a", Version: 1, ID: 1, Schema: sr.Schema{Schema: `"string"`}},
+				{Subject: "b", Version: 1, ID: 2, Schema: sr.Schema{Schema: `"int"`}},
+				{Subject: "c", Version: 1, ID: 3, Schema: sr.Schema{Schema: `"long"`}},
+			}},
+			want:      []string{"global mode IMPORT", "register a/1", "register b/1", "global mode READONLY"},
+			wantModes: map[string]string{"": "READONLY"},
+			wantErr:   true,
+		},
+		{
+			name:       "each subject is switched to IMPORT and back",
+			importMode: "subject",
+			modes:      map[string]string{"": "READWRITE", "a": "READONLY"},
+			state: &state.State{SubjectSchemas: []sr.SubjectSchema{
+				{Subject: "a", Version: 1, ID: 1, Schema: sr.Schema{Schema: `"string"`}},
+				{Subject: "b", Version: 1, ID: 2, Schema: sr.Schema{Schema: `"int"`}},
+			}},
+			want:      []string{"mode a IMPORT", "mode b IMPORT", "register a/1", "register b/1", "mode a READONLY", "reset mode b"},
+			wantModes: map[string]string{"": "READWRITE", "a": "READONLY"},
+		},
+		{
+			name:  "a hard deletion is replayed as a placeholder that is then deleted",
+			modes: map[string]string{"": "READWRITE"},
+			state: &state.State{
+				SubjectSchemas: []sr.SubjectSchema{{Subject: "a", Version: 1, ID: 1, Schema: sr.Schema{Schema: `"string"`}}},
+				HardDeletions:  []state.HardDeletion{{Subject: "a", Version: 2, ID: 2}},
+			},
+			want:      []string{"global mode IMPORT", "register a/1", "register a/2", "soft delete a/2", "hard delete a/2", "global mode READWRITE"},
+			wantModes: map[string]string{"": "READWRITE"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry, url := newFakeRegistry(t, tt.modes)
+			registry.fail = tt.fail
+			r := &RestSink{URL: url, ImportMode: tt.importMode}
+			if err := r.Connect(); err != nil {
+				t.Fatal(err)
+			}
+
+			err := r.PutState(context.Background(), tt.state)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want an error %v", err, tt.wantErr)
+			}
+
+			registry.mu.Lock()
+			defer registry.mu.Unlock()
+			// Subjects are switched concurrently, so only their order within
+			// each step is unknown
+			got := slices.Clone(registry.requests)
+			if tt.importMode == "subject" && len(got) == len(tt.want) {
+				slices.Sort(got[:2])
+				slices.Sort(got[4:])
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got requests %q, want %q", got, tt.want)
+			}
+			if !maps.Equal(registry.modes, tt.wantModes) {
+				t.Errorf("got modes %v, want %v", registry.modes, tt.wantModes)
+			}
+		})
+	}
+}
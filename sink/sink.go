@@ -0,0 +1,12 @@
+// Package sink writes a state into a schema registry, its _schemas topic or a
+// file
+package sink
+
+import (
+	"context"
+	"go-schema-migrator/state"
+)
+
+type Sink interface {
+	PutState(ctx context.Context, state *state.State) error
+}
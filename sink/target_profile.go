@@ -0,0 +1,85 @@
+package sink
+
+import (
+	"fmt"
+	"github.com/twmb/franz-go/pkg/sr"
+	"go-schema-migrator/state"
+	"go-schema-migrator/validation"
+	"slices"
+)
+
+// targetProfile describes what a kind of registry accepts, so that sinks only
+// write what the target supports
+type targetProfile struct {
+	name         string
+	configFields []string
+	schemaFields []string
+}
+
+var allConfigFields = []string{
+	"compatibilityLevel",
+	"alias",
+	"normalize",
+	"compatibilityGroup",
+	"defaultMetadata",
+	"overrideMetadata",
+	"defaultRuleSet",
+	"overrideRuleSet",
+}
+
+var allSchemaFields = []string{
+	"metadata",
+	"ruleSet",
+}
+
+var targetProfiles = map[string]targetProfile{
+	"redpanda":  {name: "redpanda", configFields: []string{"compatibilityLevel"}},
+	"confluent": {name: "confluent", configFields: allConfigFields, schemaFields: allSchemaFields},
+	"karapace":  {name: "karapace", configFields: []string{"compatibilityLevel"}},
+	"apicurio":  {name: "apicurio", configFields: []string{"compatibilityLevel"}},
+}
+
+// getTargetProfile returns the named profile, defaulting to redpanda
+func getTargetProfile(name string) (targetProfile, error) {
+	if name == "" {
+		name = "redpanda"
+	}
+	profile, ok := targetProfiles[name]
+	if !ok {
+		return targetProfile{}, fmt.Errorf("unknown target: %v", name)
+	}
+	return profile, nil
+}
+
+// filterCompatibility returns the compatibility result with the fields that
+// the target doesn't support cleared, reporting each that had a value
+func (p targetProfile) filterCompatibility(report *validation.Report, result sr.CompatibilityResult) sr.CompatibilityResult {
+	drop := func(field string, set bool, value any, clear func()) {
+		if set && !slices.Contains(p.configFields, field) {
+			report.Warnf("dropped_config_field", result.Subject, 0, "%v %v is not supported by target %v and was not written", field, validation.Describe(value), p.name)
+			clear()
+		}
+	}
+	drop("alias", result.Alias != "", result.Alias, func() { result.Alias = "" })
+	drop("normalize", result.Normalize, result.Normalize, func() { result.Normalize = false })
+	drop("compatibilityGroup", result.Group != "", result.Group, func() { result.Group = "" })
+	drop("defaultMetadata", result.DefaultMetadata != nil, result.DefaultMetadata, func() { result.DefaultMetadata = nil })
+	drop("overrideMetadata", result.OverrideMetadata != nil, result.OverrideMetadata, func() { result.OverrideMetadata = nil })
+	drop("defaultRuleSet", result.DefaultRuleSet != nil, result.DefaultRuleSet, func() { result.DefaultRuleSet = nil })
+	drop("overrideRuleSet", result.OverrideRuleSet != nil, result.OverrideRuleSet, func() { result.OverrideRuleSet = nil })
+	return result
+}
+
+// filterSchema returns the subject schema with the metadata and rule set
+// cleared if the target doesn't support them, reporting each that had a value
+func (p targetProfile) filterSchema(report *validation.Report, subjectSchema sr.SubjectSchema) sr.SubjectSchema {
+	drop := func(field string, value any, clear func()) {
+		if !state.Equivalent(value, nil) && !slices.Contains(p.schemaFields, field) {
+			report.Warnf("dropped_schema_field", subjectSchema.Subject, subjectSchema.Version, "%v %v is not supported by target %v and was not written", field, validation.Describe(value), p.name)
+			clear()
+		}
+	}
+	drop("metadata", subjectSchema.SchemaMetadata, func() { subjectSchema.SchemaMetadata = nil })
+	drop("ruleSet", subjectSchema.SchemaRuleSet, func() { subjectSchema.SchemaRuleSet = nil })
+	return subjectSchema
+}
@@ -0,0 +1,54 @@
+package sink
+
+import (
+	"testing"
+
+	"github.com/twmb/franz-go/pkg/sr"
+	"go-schema-migrator/validation"
+)
+
+func TestFilterSchema(t *testing.T) {
+	subjectSchema := sr.SubjectSchema{Subject: "a", Version: 1, ID: 1, Schema: sr.Schema{
+		Schema:         `"string"`,
+		SchemaMetadata: &sr.SchemaMetadata{Properties: map[string]string{"owner": "team"}},
+		SchemaRuleSet:  &sr.SchemaRuleSet{DomainRules: []sr.SchemaRule{{Name: "rule", Kind: sr.SchemaRuleKindCondition}}},
+	}}
+
+	tests := []struct {
+		target  string
+		schema  sr.SubjectSchema
+		kept    bool
+		dropped int
+	}{
+		{target: "confluent", schema: subjectSchema, kept: true},
+		{target: "redpanda", schema: subjectSchema, dropped: 2},
+		{target: "karapace", schema: subjectSchema, dropped: 2},
+		{target: "apicurio", schema: subjectSchema, dropped: 2},
+		{target: "redpanda", schema: sr.SubjectSchema{Subject: "a", Version: 1, ID: 1, Schema: sr.Schema{
+			Schema:         `"string"`,
+			SchemaMetadata: &sr.SchemaMetadata{},
+		}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			profile, err := getTargetProfile(tt.target)
+			if err != nil {
+				t.Fatal(err)
+			}
+			report := validation.NewReport()
+			got := profile.filterSchema(report, tt.schema)
+			if kept := got.SchemaMetadata != nil && got.SchemaRuleSet != nil; kept != tt.kept {
+				t.Errorf("got metadata %v and rule set %v, want kept %v", got.SchemaMetadata, got.SchemaRuleSet, tt.kept)
+			}
+			if len(report.Problems) != tt.dropped {
+				t.Errorf("got %v, want %v dropped fields", report.Problems, tt.dropped)
+			}
+			for _, problem := range report.Problems {
+				if problem.Check != "dropped_schema_field" || problem.Subject != "a" || problem.Version != 1 {
+					t.Errorf("unexpected problem: %+v", problem)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,359 @@
+package sink
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/sr"
+	"go-schema-migrator/client"
+	"go-schema-migrator/state"
+	"go-schema-migrator/validation"
+	"time"
+)
+
+type TopicSink struct {
+	Seed          string             `koanf:"seed"`
+	Topic         string             `koanf:"topic"`
+	Compatibility string             `koanf:"compatibility"`
+	SASL          *client.SASLConfig `koanf:"sasl"`
+	TLS           *client.TLSConfig  `koanf:"tls"`
+	Target        string             `koanf:"target"`
+
+	ContextConfig `koanf:",squash"`
+
+	profile targetProfile
+	report  *validation.Report
+}
+
+func (t *TopicSink) Connect() error {
+	profile, err := getTargetProfile(t.Target)
+	if err != nil {
+		return err
+	}
+	t.profile = profile
+	t.report = validation.NewReport()
+	return nil
+}
+
+// Report returns what was lost in writing to the target
+func (t *TopicSink) Report() *validation.Report {
+	return t.report
+}
+
+func (t *TopicSink) createSubjectSchemaRecord(value sr.SubjectSchema, deleted bool) (*kgo.Record, error) {
+	key := make(map[string]interface{})
+
+	key["keytype"] = "SCHEMA"
+	key["subject"] = value.Subject
+	key["version"] = value.Version
+	key["magic"] = 1
+
+	keyBytes, err := json.Marshal(key)
+	if err != nil {
+		return nil, fmt.Errorf("unable to marshall key into json: %v", key)
+	}
+
+	valueBytes, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("unable to marshall value into json: %v", value)
+	}
+	var v interface{}
+	err = json.Unmarshal(valueBytes, &v)
+	if err != nil {
+		return nil, fmt.Errorf("unable to marshall value into json: %v", value)
+	}
+	vm := v.(map[string]interface{})
+	vm["deleted"] = deleted
+	//_, found := vm["references"]
+	//if !found {
+	//	vm["references"] = []string{}
+	//}
+	valueBytes, err = json.Marshal(v)
+
+	record := &kgo.Record{
+		Key:       keyBytes,
+		Value:     valueBytes,
+		Timestamp: time.Time{},
+		Topic:     t.Topic,
+		Partition: 0,
+	}
+
+	return record, nil
+}
+
+func (t *TopicSink) createCompatibilityRecord(value sr.CompatibilityResult) (*kgo.Record, error) {
+
+	key := make(map[string]interface{})
+
+	key["keytype"] = "CONFIG"
+	key["magic"] = 0
+	if value.Subject != "" {
+		key["subject"] = value.Subject
+	}
+
+	keyBytes, err := json.Marshal(key)
+	if err != nil {
+		return nil, fmt.Errorf("unable to marshal key into json: %v", key)
+	}
+
+	// Only write the fields that the target supports
+	value = t.profile.filterCompatibility(t.report, value)
+
+	valueBytes, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("unable to marshal value into json: %v", value)
+	}
+
+	var v interface{}
+	err = json.Unmarshal(valueBytes, &v)
+	if err != nil {
+		return nil, fmt.Errorf("unable to unmarshal value into json: %v", value)
+	}
+	vm := v.(map[string]interface{})
+	// We delete keys that aren't set
+	for key, field := range vm {
+		if field == nil || field == "" || field == false {
+			delete(vm, key)
+		}
+	}
+	// Finally we ship the amended record
+	valueBytes, err = json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("unable to marshal amended value into json: %v", value)
+	}
+
+	record := &kgo.Record{
+		Key:       keyBytes,
+		Value:     valueBytes,
+		Timestamp: time.Time{},
+		Topic:     t.Topic,
+		Partition: 0,
+	}
+
+	return record, nil
+}
+
+func (t *TopicSink) createTombstoneRecord(subject string, version int) (*kgo.Record, error) {
+	key := make(map[string]interface{})
+
+	key["keytype"] = "SCHEMA"
+	key["subject"] = subject
+	key["version"] = version
+	key["magic"] = 1
+
+	keyBytes, err := json.Marshal(key)
+	if err != nil {
+		return nil, fmt.Errorf("unable to marshal key into json: %v", key)
+	}
+
+	record := &kgo.Record{
+		Key:       keyBytes,
+		Value:     nil,
+		Timestamp: time.Time{},
+		Topic:     t.Topic,
+		Partition: 0,
+	}
+
+	return record, nil
+}
+
+func (t *TopicSink) createModeRecord(subject string, mode sr.Mode) (*kgo.Record, error) {
+	key := make(map[string]interface{})
+
+	key["keytype"] = "MODE"
+	key["magic"] = 0
+	if subject != "" {
+		key["subject"] = subject
+	}
+
+	keyBytes, err := json.Marshal(key)
+	if err != nil {
+		return nil, fmt.Errorf("unable to marshal key into json: %v", key)
+	}
+
+	value := client.TopicModeValue{Mode: mode}
+	valueBytes, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("unable to marshal value into json: %v", value)
+	}
+
+	record := &kgo.Record{
+		Key:       keyBytes,
+		Value:     valueBytes,
+		Timestamp: time.Time{},
+		Topic:     t.Topic,
+		Partition: 0,
+	}
+
+	return record, nil
+}
+
+func (t *TopicSink) createDeleteSubjectRecord(subject string, version int) (*kgo.Record, error) {
+	key := make(map[string]interface{})
+
+	key["keytype"] = "DELETE_SUBJECT"
+	key["subject"] = subject
+	key["magic"] = 0
+
+	keyBytes, err := json.Marshal(key)
+	if err != nil {
+		return nil, fmt.Errorf("unable to marshal key into json: %v", key)
+	}
+
+	value := client.TopicDeleteSubjectValue{Subject: subject, Version: version}
+	valueBytes, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("unable to marshal value into json: %v", value)
+	}
+
+	record := &kgo.Record{
+		Key:       keyBytes,
+		Value:     valueBytes,
+		Timestamp: time.Time{},
+		Topic:     t.Topic,
+		Partition: 0,
+	}
+
+	return record, nil
+}
+
+func (t *TopicSink) GetRecords(state *state.State) ([]*kgo.Record, error) {
+	records := make([]*kgo.Record, 0)
+
+	// Write opening compatibility level
+	record := &kgo.Record{Topic: t.Topic, Key: []byte("{\"keytype\":\"CONFIG\",\"magic\":0}"), Value: []byte("{\"compatibilityLevel\":\"NONE\"}")}
+	records = append(records, record)
+
+	// Write subject versions
+	for _, subjectSchema := range state.SubjectSchemas {
+		ref := sr.SubjectVersion{Subject: subjectSchema.Subject, Version: subjectSchema.Version}
+
+		// Find out whether this subject version has been deleted
+		deleted := false
+		for _, deletion := range state.SoftDeletions {
+			if areEqual(ref, deletion) {
+				deleted = true
+				break
+			}
+		}
+
+		// Write out record
+		subjectSchema = t.profile.filterSchema(t.report, subjectSchema)
+		record, err := t.createSubjectSchemaRecord(subjectSchema, deleted)
+		if err != nil {
+			return nil, fmt.Errorf("unable to create subject schema record: %w", err)
+		}
+		records = append(records, record)
+	}
+
+	// Write hard deletions and reserved IDs. The placeholder moves the
+	// registry's ID counter past the ID, and the tombstone then removes it.
+	replays := append(state.HardDeletionsToReplay(), state.ReservedIDsToReplay(t.ContextConfig.Context)...)
+	for _, deletion := range replays {
+		placeholder := sr.SubjectSchema{Subject: deletion.Subject, Version: deletion.Version, ID: deletion.ID, Schema: deletion.PlaceholderSchema()}
+		record, err := t.createSubjectSchemaRecord(placeholder, true)
+		if err != nil {
+			return nil, fmt.Errorf("unable to create subject schema record: %w", err)
+		}
+		records = append(records, record)
+		record, err = t.createTombstoneRecord(deletion.Subject, deletion.Version)
+		if err != nil {
+			return nil, fmt.Errorf("unable to create tombstone record: %w", err)
+		}
+		records = append(records, record)
+	}
+
+	// Write subject deletions, covering every version of the subject
+	latestVersions := make(map[string]int)
+	for _, subjectSchema := range state.SubjectSchemas {
+		latestVersions[subjectSchema.Subject] = max(latestVersions[subjectSchema.Subject], subjectSchema.Version)
+	}
+	for _, subject := range state.DeletedSubjects {
+		record, err := t.createDeleteSubjectRecord(subject, latestVersions[subject])
+		if err != nil {
+			return nil, fmt.Errorf("unable to create delete subject record: %w", err)
+		}
+		records = append(records, record)
+	}
+
+	//Write subject compatibilities
+	for _, result := range state.CompatibilityResults {
+		record, err := t.createCompatibilityRecord(result)
+		if err != nil {
+			return nil, fmt.Errorf("unable to convert compatibility record into record: %w", err)
+		}
+		records = append(records, record)
+	}
+
+	// Write closing compatibility level, configured on the sink or else taken from the source
+	if t.Compatibility != "" {
+		record = &kgo.Record{Topic: t.Topic, Key: []byte("{\"keytype\":\"CONFIG\",\"magic\":0}"), Value: []byte(fmt.Sprintf("{\"compatibilityLevel\":\"%v\"}", t.Compatibility))}
+		records = append(records, record)
+	} else if state.GlobalCompatibility != nil {
+		globalCompatibility := *state.GlobalCompatibility
+		globalCompatibility.Subject = ""
+		record, err := t.createCompatibilityRecord(globalCompatibility)
+		if err != nil {
+			return nil, fmt.Errorf("unable to convert compatibility record into record: %w", err)
+		}
+		records = append(records, record)
+	} else {
+		// Without a level to close with, remove the opening level so the registry default applies
+		record = &kgo.Record{Topic: t.Topic, Key: []byte("{\"keytype\":\"CONFIG\",\"magic\":0}")}
+		records = append(records, record)
+	}
+
+	// Write subject modes, and then the global mode
+	for _, result := range state.Modes {
+		record, err := t.createModeRecord(result.Subject, result.Mode)
+		if err != nil {
+			return nil, fmt.Errorf("unable to create mode record: %w", err)
+		}
+		records = append(records, record)
+	}
+	if state.GlobalMode != nil {
+		record, err := t.createModeRecord("", *state.GlobalMode)
+		if err != nil {
+			return nil, fmt.Errorf("unable to create mode record: %w", err)
+		}
+		records = append(records, record)
+	}
+
+	return records, nil
+}
+
+func (t *TopicSink) PutState(ctx context.Context, state *state.State) error {
+	state, err := t.ContextConfig.apply(state)
+	if err != nil {
+		return fmt.Errorf("unable to map contexts: %w", err)
+	}
+
+	records, err := t.GetRecords(state)
+	if err != nil {
+		return fmt.Errorf("unable to convert state into records")
+	}
+
+	opts, err := client.KafkaOpts(t.Seed, t.SASL, t.TLS)
+	if err != nil {
+		return err
+	}
+
+	cl, err := kgo.NewClient(opts...)
+	if err != nil {
+		return fmt.Errorf("unable to create kafka client: %w", err)
+	}
+	defer cl.Close()
+
+	for _, record := range records {
+		if err := cl.ProduceSync(ctx, record).FirstErr(); err != nil {
+			return fmt.Errorf("unable to produce record: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func areEqual(a, b sr.SubjectVersion) bool {
+	return a.Subject == b.Subject && a.Version == b.Version
+}
@@ -1,5 +0,0 @@
-package main
-
-type Source interface {
-	GetState() (*State, error)
-}
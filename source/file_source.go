@@ -1,7 +1,9 @@
-package main
+package source
 
 import (
+	"context"
 	"fmt"
+	"go-schema-migrator/state"
 	"gopkg.in/yaml.v3"
 	"os"
 )
@@ -10,8 +12,8 @@ type FileSource struct {
 	Filename string `koanf:"filename"`
 }
 
-func (f *FileSource) GetState() (*State, error) {
-	var state State
+func (f *FileSource) GetState(ctx context.Context) (*state.State, error) {
+	var state state.State
 	data, err := os.ReadFile(f.Filename)
 	if err != nil {
 		return nil, fmt.Errorf("unable to read %v: %w", f.Filename, err)
@@ -0,0 +1,221 @@
+package source
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/PaesslerAG/jsonpath"
+	"github.com/twmb/franz-go/pkg/sr"
+	"go-schema-migrator/state"
+	"math"
+	"os"
+)
+
+type FileSourceV1 struct {
+	Filename string
+}
+
+// decodeOptional decodes the value found at path into into, leaving into as
+// it is if there is no such value
+func decodeOptional(v interface{}, path string, into interface{}) error {
+	value, err := jsonpath.Get(path, v)
+	if err != nil || value == nil {
+		return nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, into)
+}
+
+func (f *FileSourceV1) GetState(ctx context.Context) (*state.State, error) {
+
+	subjectSchemas := make([]sr.SubjectSchema, 0)
+	compatibilityResults := make([]sr.CompatibilityResult, 0)
+	softDeletions := make([]sr.SubjectVersion, 0)
+	modes := make([]sr.ModeResult, 0)
+	var globalCompatibility *sr.CompatibilityResult
+	var globalMode *sr.Mode
+
+	// Open the file
+	file, err := os.Open(f.Filename)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open file: %w", err)
+	}
+	defer func(file *os.File) {
+		_ = file.Close()
+	}(file)
+	// Create a scanner
+	scanner := bufio.NewScanner(file)
+	lineNumber := 0
+	// Read and print lines
+	for scanner.Scan() {
+		lineNumber++
+		line := scanner.Text()
+		//data := make(map[string]interface{})
+		v := interface{}(nil)
+		err = json.Unmarshal([]byte(line), &v)
+		if err != nil {
+			return nil, fmt.Errorf("unable to unmarshall json at line %v: %w", lineNumber, err)
+		}
+
+		keytype, err := jsonpath.Get("$.key.keytype", v)
+		if err != nil {
+			return nil, fmt.Errorf("unable to find key.keytype at line %v: %w", lineNumber, err)
+		}
+
+		if keytype == "CONFIG" {
+			// A config record without a level, such as one written when only
+			// the mode was set, has no compatibility to migrate
+			level, err := jsonpath.Get("$.value.compatibilityLevel", v)
+			if err != nil || level == nil {
+				continue
+			}
+			var compatibilityLevel sr.CompatibilityLevel
+			err = compatibilityLevel.UnmarshalText([]byte(fmt.Sprintf("%v", level)))
+			if err != nil {
+				return nil, fmt.Errorf("unable to unmarshall compatibility level at line %v: %w", lineNumber, err)
+			}
+			subject, err := jsonpath.Get("$.key.subject", v)
+			if err != nil && !(fmt.Sprintf("%v", err) == "unknown key subject") {
+				return nil, fmt.Errorf("unable to find subject in key at line %v: %w", lineNumber, err)
+			}
+			compatibilityResult := sr.CompatibilityResult{Level: compatibilityLevel}
+			err = decodeOptional(v, "$.value.defaultRuleSet", &compatibilityResult.DefaultRuleSet)
+			if err != nil {
+				return nil, fmt.Errorf("unable to unmarshall default rule set at line %v: %w", lineNumber, err)
+			}
+			err = decodeOptional(v, "$.value.overrideRuleSet", &compatibilityResult.OverrideRuleSet)
+			if err != nil {
+				return nil, fmt.Errorf("unable to unmarshall override rule set at line %v: %w", lineNumber, err)
+			}
+			// The global compatibility level has no subject
+			if subject == nil {
+				globalCompatibility = &compatibilityResult
+			} else {
+				compatibilityResult.Subject = subject.(string)
+				compatibilityResults = append(compatibilityResults, compatibilityResult)
+			}
+		}
+		if keytype == "MODE" {
+			modeText, err := jsonpath.Get("$.value.mode", v)
+			if err != nil {
+				return nil, fmt.Errorf("unable to find mode at line %v: %w", lineNumber, err)
+			}
+			var mode sr.Mode
+			err = mode.UnmarshalText([]byte(modeText.(string)))
+			if err != nil {
+				return nil, fmt.Errorf("unable to unmarshall mode at line %v: %w", lineNumber, err)
+			}
+			subject, err := jsonpath.Get("$.key.subject", v)
+			if err != nil && !(fmt.Sprintf("%v", err) == "unknown key subject") {
+				return nil, fmt.Errorf("unable to find subject in key at line %v: %w", lineNumber, err)
+			}
+			// The global mode has no subject
+			if subject == nil {
+				globalMode = &mode
+			} else {
+				modes = append(modes, sr.ModeResult{Subject: subject.(string), Mode: mode})
+			}
+		}
+		if keytype == "SCHEMA" {
+			subject, err := jsonpath.Get("$.key.subject", v)
+			if err != nil {
+				return nil, fmt.Errorf("unable to find key.subject at line %v: %w", lineNumber, err)
+			}
+			version, err := jsonpath.Get("$.key.version", v)
+			if err != nil {
+				return nil, fmt.Errorf("unable to find key.version at line %v: %w", lineNumber, err)
+			}
+			id, err := jsonpath.Get("$.value.id", v)
+			if err != nil {
+				return nil, fmt.Errorf("unable to find value.id at line %v: %w", lineNumber, err)
+			}
+			schemaTypeText, err := jsonpath.Get("$.value.schemaType", v)
+			if err != nil {
+				return nil, fmt.Errorf("unable to find value.schemaType at line %v: %w", lineNumber, err)
+			}
+			schema, err := jsonpath.Get("$.value.schema", v)
+			if err != nil {
+				return nil, fmt.Errorf("unable to find value.schema at line %v: %w", lineNumber, err)
+			}
+			deleted, err := jsonpath.Get("$.value.deleted", v)
+			if err != nil {
+				return nil, fmt.Errorf("unable to find value.deleted at line %v: %w", lineNumber, err)
+			}
+			if deleted.(bool) {
+				softDeletions = append(softDeletions, sr.SubjectVersion{
+					Subject: subject.(string),
+					Version: int(math.Round(version.(float64))),
+				})
+			}
+			schemaReferences := make([]sr.SchemaReference, 0)
+			references, err := jsonpath.Get("$.value.references", v)
+			if err == nil {
+				for _, reference := range references.([]interface{}) {
+					m := reference.(map[string]interface{})
+					ref := sr.SchemaReference{
+						Name:    m["name"].(string),
+						Subject: m["subject"].(string),
+						Version: int(math.Round(m["version"].(float64))),
+					}
+					schemaReferences = append(schemaReferences, ref)
+				}
+			}
+
+			var schemaType sr.SchemaType
+			err = schemaType.UnmarshalText([]byte(schemaTypeText.(string)))
+			if err != nil {
+				return nil, fmt.Errorf("unable to unmarshall schemaType at line %v: %w", lineNumber, err)
+			}
+
+			// To match what we get from REST
+			if len(schemaReferences) == 0 {
+				schemaReferences = nil
+			}
+
+			var schemaMetadata *sr.SchemaMetadata
+			err = decodeOptional(v, "$.value.metadata", &schemaMetadata)
+			if err != nil {
+				return nil, fmt.Errorf("unable to unmarshall metadata at line %v: %w", lineNumber, err)
+			}
+			var schemaRuleSet *sr.SchemaRuleSet
+			err = decodeOptional(v, "$.value.ruleSet", &schemaRuleSet)
+			if err != nil {
+				return nil, fmt.Errorf("unable to unmarshall rule set at line %v: %w", lineNumber, err)
+			}
+
+			subjectSchema := sr.SubjectSchema{
+				Subject: subject.(string),
+				Version: int(math.Round(version.(float64))),
+				ID:      int(math.Round(id.(float64))),
+				Schema: sr.Schema{
+					Schema:         schema.(string),
+					Type:           schemaType,
+					References:     schemaReferences,
+					SchemaMetadata: schemaMetadata,
+					SchemaRuleSet:  schemaRuleSet,
+				},
+			}
+
+			subjectSchemas = append(subjectSchemas, subjectSchema)
+		}
+	}
+	// Check for errors
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("scanner resulted in an error: %w", err)
+	}
+
+	var result state.State
+	result.SubjectSchemas = subjectSchemas
+	result.CompatibilityResults = compatibilityResults
+	result.SoftDeletions = softDeletions
+	result.DeletedSubjects = result.AllVersionsDeleted()
+	result.GlobalCompatibility = globalCompatibility
+	result.GlobalMode = globalMode
+	result.Modes = modes
+
+	return &result, nil
+}
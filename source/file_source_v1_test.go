@@ -0,0 +1,40 @@
+package source
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/twmb/franz-go/pkg/sr"
+	"go-schema-migrator/state"
+)
+
+// TestFileSourceV1 reads an export holding config records that don't set a
+// compatibility level, which are skipped
+func TestFileSourceV1(t *testing.T) {
+	mode := sr.ModeReadWrite
+	want := &state.State{
+		SubjectSchemas: []sr.SubjectSchema{
+			{Subject: "a", Version: 1, ID: 1, Schema: sr.Schema{Schema: `"string"`, Type: sr.TypeAvro}},
+			{Subject: "b", Version: 1, ID: 2, Schema: sr.Schema{
+				Schema:     `"int"`,
+				Type:       sr.TypeAvro,
+				References: []sr.SchemaReference{{Name: "a", Subject: "a", Version: 1}},
+			}},
+		},
+		CompatibilityResults: []sr.CompatibilityResult{{Subject: "a", Level: sr.CompatFull}},
+		SoftDeletions:        []sr.SubjectVersion{{Subject: "b", Version: 1}},
+		DeletedSubjects:      []string{"b"},
+		GlobalCompatibility:  &sr.CompatibilityResult{Level: sr.CompatBackward},
+		GlobalMode:           &mode,
+		Modes:                []sr.ModeResult{{Subject: "a", Mode: sr.ModeReadOnly}},
+	}
+
+	got, err := (&FileSourceV1{Filename: "testdata/export_v1.jsonl"}).GetState(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,375 @@
+package source
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/twmb/franz-go/pkg/sr"
+	"go-schema-migrator/client"
+	"go-schema-migrator/internal/collections"
+	"go-schema-migrator/state"
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/time/rate"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+type RestSource struct {
+	URL         string             `koanf:"url"`
+	Username    string             `koanf:"username"`
+	Password    string             `koanf:"password"`
+	TLS         *tls.Config        `koanf:"tls"`
+	Concurrency int                `koanf:"concurrency"`
+	RateLimit   float64            `koanf:"rate_limit"`
+	Retry       client.RetryConfig `koanf:"retry"`
+
+	client     *sr.Client
+	httpClient *http.Client
+	limiter    *rate.Limiter
+}
+
+func (r *RestSource) Connect() error {
+	opts := make([]sr.ClientOpt, 0)
+	opts = append(opts, sr.URLs(r.URL))
+	opts = append(opts, sr.BasicAuth(r.Username, r.Password))
+	opts = append(opts, sr.DialTLSConfig(r.TLS))
+	client, err := sr.NewClient(opts...)
+	if err != nil {
+		return fmt.Errorf("unable to create registry client: %w", err)
+	}
+	r.client = client
+	r.httpClient = &http.Client{Transport: &http.Transport{Proxy: http.ProxyFromEnvironment, TLSClientConfig: r.TLS}}
+
+	if r.Concurrency < 1 {
+		r.Concurrency = 1
+	}
+	r.limiter = rate.NewLimiter(rate.Inf, 1)
+	if r.RateLimit > 0 {
+		r.limiter = rate.NewLimiter(rate.Limit(r.RateLimit), 1)
+	}
+	return nil
+}
+
+// call makes a request to the registry, waiting for the rate limit before
+// each attempt and retrying according to the retry policy
+func call[T any](ctx context.Context, r *RestSource, fn func(context.Context) (T, error)) (T, error) {
+	return client.Retry(ctx, r.Retry, func(attemptCtx context.Context) (T, error) {
+		if err := r.limiter.Wait(ctx); err != nil {
+			var zero T
+			return zero, err
+		}
+		return fn(attemptCtx)
+	})
+}
+
+// forEach calls fn for every item, running at most Concurrency calls at once.
+// Results should be written to a slot per index, so that the order they are
+// gathered in doesn't depend on the order the calls finish in.
+func forEach[T any](concurrency int, items []T, fn func(int, T) error) error {
+	var group errgroup.Group
+	group.SetLimit(concurrency)
+	for i, item := range items {
+		group.Go(func() error {
+			return fn(i, item)
+		})
+	}
+	return group.Wait()
+}
+
+// getContexts returns the contexts in the registry, or just the default context
+// if the registry doesn't support contexts
+func (r *RestSource) getContexts(ctx context.Context) ([]string, error) {
+	// The client has no call for listing contexts, so this is made directly
+	contexts, err := call(ctx, r, func(ctx context.Context) ([]string, error) {
+		// As with the client, a URL without a scheme is taken to be http
+		baseURL := r.URL
+		if !strings.HasPrefix(baseURL, "http://") && !strings.HasPrefix(baseURL, "https://") {
+			baseURL = "http://" + baseURL
+		}
+		requestURL, err := url.JoinPath(baseURL, "contexts")
+		if err != nil {
+			return nil, err
+		}
+		request, err := http.NewRequestWithContext(ctx, http.MethodGet, requestURL, nil)
+		if err != nil {
+			return nil, err
+		}
+		request.Header.Set("Accept", "application/vnd.schemaregistry.v1+json")
+		if r.Username != "" || r.Password != "" {
+			request.SetBasicAuth(r.Username, r.Password)
+		}
+		response, err := r.httpClient.Do(request)
+		if err != nil {
+			return nil, err
+		}
+		defer response.Body.Close()
+		body, err := io.ReadAll(response.Body)
+		if err != nil {
+			return nil, err
+		}
+		if response.StatusCode >= 300 {
+			return nil, &sr.ResponseError{Method: http.MethodGet, URL: requestURL, StatusCode: response.StatusCode, Raw: body}
+		}
+		var contexts []string
+		err = json.Unmarshal(body, &contexts)
+		return contexts, err
+	})
+	if client.IsNotFound(err) {
+		return []string{state.DefaultContext}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to retrieve contexts: %w", err)
+	}
+
+	result := collections.ToMap([]string{state.DefaultContext})
+	for _, context := range contexts {
+		result[state.NormalizeContext(context)] = true
+	}
+	return slices.Sorted(maps.Keys(result)), nil
+}
+
+// getSubjects returns the live subjects of a context, and the subjects that
+// have been soft deleted (those that are only listed when deleted subjects are
+// included). Subjects outside the default context are qualified by their context.
+func (r *RestSource) getSubjects(ctx context.Context, schemaContext string) (map[string]bool, map[string]bool, error) {
+	params := make([]sr.Param, 0)
+	if schemaContext != state.DefaultContext {
+		params = append(params, sr.SubjectPrefix(state.Qualify(schemaContext, "")))
+	}
+
+	allSubjectsResponse, err := call(ctx, r, func(ctx context.Context) ([]string, error) {
+		return r.client.Subjects(sr.WithParams(ctx, append(params, sr.ShowDeleted)...))
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to retrieve subjects (incl deleted): %w", err)
+	}
+	deletedSubjects := collections.ToMap(allSubjectsResponse)
+
+	subjectsResponse, err := call(ctx, r, func(ctx context.Context) ([]string, error) {
+		return r.client.Subjects(sr.WithParams(ctx, params...))
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to retrieve subjects: %w", err)
+	}
+	subjects := collections.ToMap(subjectsResponse)
+
+	for _, subject := range subjectsResponse {
+		delete(deletedSubjects, subject)
+	}
+
+	// Guard against registries that list more than the context asked for
+	outsideContext := func(subject string, _ bool) bool {
+		return state.SubjectContext(subject) != schemaContext
+	}
+	maps.DeleteFunc(subjects, outsideContext)
+	maps.DeleteFunc(deletedSubjects, outsideContext)
+
+	return subjects, deletedSubjects, nil
+}
+
+// getVersions returns the live versions of a subject, and the versions that
+// have been soft deleted
+func (r *RestSource) getVersions(ctx context.Context, subject string) (map[int]bool, map[int]bool, error) {
+	allVersionsResponse, err := call(ctx, r, func(ctx context.Context) ([]int, error) {
+		return r.client.SubjectVersions(sr.WithParams(ctx, sr.ShowDeleted), subject)
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to retrieve subject versions (incl deleted): %w", err)
+	}
+	deletedVersions := collections.ToMap(allVersionsResponse)
+
+	// A soft deleted subject has no live versions, and isn't found without the deleted flag
+	versionsResponse, err := call(ctx, r, func(ctx context.Context) ([]int, error) {
+		return r.client.SubjectVersions(ctx, subject)
+	})
+	if err != nil && !client.IsNotFound(err) {
+		return nil, nil, fmt.Errorf("unable to retrieve subject versions: %w", err)
+	}
+	versions := collections.ToMap(versionsResponse)
+	for _, version := range versionsResponse {
+		delete(deletedVersions, version)
+	}
+	return versions, deletedVersions, nil
+}
+
+func (r *RestSource) getSubjectSchema(ctx context.Context, subject string, version int) (*sr.SubjectSchema, error) {
+	subjectSchema, err := call(ctx, r, func(ctx context.Context) (sr.SubjectSchema, error) {
+		return r.client.SchemaByVersion(sr.WithParams(ctx, sr.ShowDeleted), subject, version)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &subjectSchema, nil
+}
+
+// AllSchemas returns every schema in the registry, including those that have
+// been soft deleted
+func (r *RestSource) AllSchemas(ctx context.Context) ([]sr.SubjectSchema, error) {
+	return call(ctx, r, func(ctx context.Context) ([]sr.SubjectSchema, error) {
+		return r.client.AllSchemas(sr.WithParams(ctx, sr.ShowDeleted))
+	})
+}
+
+// subjectVersions holds the versions found for a subject
+type subjectVersions struct {
+	versions        []int
+	deletedVersions []int
+}
+
+// schemaRequest identifies a subject version to fetch
+type schemaRequest struct {
+	subject string
+	version int
+	deleted bool
+}
+
+func (r *RestSource) GetState(ctx context.Context) (*state.State, error) {
+	contexts, err := r.getContexts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Each context is exported, with its subjects qualified by the context
+	subjects := make(map[string]bool)
+	deletedSubjects := make(map[string]bool)
+	for _, schemaContext := range contexts {
+		contextSubjects, contextDeletedSubjects, err := r.getSubjects(ctx, schemaContext)
+		if err != nil {
+			return nil, err
+		}
+		maps.Copy(subjects, contextSubjects)
+		maps.Copy(deletedSubjects, contextDeletedSubjects)
+	}
+
+	// Subjects are fetched in a fixed order, so the state is the same from run to run
+	allSubjects := slices.Sorted(maps.Keys(subjects))
+	allSubjects = append(allSubjects, slices.Sorted(maps.Keys(deletedSubjects))...)
+
+	versions := make([]subjectVersions, len(allSubjects))
+	err = forEach(r.Concurrency, allSubjects, func(i int, subject string) error {
+		live, deleted, err := r.getVersions(ctx, subject)
+		if err != nil {
+			return err
+		}
+		versions[i] = subjectVersions{
+			versions:        slices.Sorted(maps.Keys(live)),
+			deletedVersions: slices.Sorted(maps.Keys(deleted)),
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	requests := make([]schemaRequest, 0)
+	for i, subject := range allSubjects {
+		for _, version := range versions[i].versions {
+			requests = append(requests, schemaRequest{subject: subject, version: version})
+		}
+		for _, version := range versions[i].deletedVersions {
+			requests = append(requests, schemaRequest{subject: subject, version: version, deleted: true})
+		}
+	}
+
+	subjectSchemas := make([]sr.SubjectSchema, len(requests))
+	err = forEach(r.Concurrency, requests, func(i int, request schemaRequest) error {
+		subjectSchema, err := r.getSubjectSchema(ctx, request.subject, request.version)
+		if err != nil {
+			return fmt.Errorf("unable to retrieve schemas: %w", err)
+		}
+		subjectSchemas[i] = *subjectSchema
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	softDeletions := make([]sr.SubjectVersion, 0)
+	for _, request := range requests {
+		if request.deleted {
+			softDeletions = append(softDeletions, sr.SubjectVersion{Subject: request.subject, Version: request.version})
+		}
+	}
+
+	liveSubjects := slices.Sorted(maps.Keys(subjects))
+	rawCompatibilityResults := make([]sr.CompatibilityResult, len(liveSubjects))
+	err = forEach(r.Concurrency, liveSubjects, func(i int, subject string) error {
+		// A subject without its own compatibility level fails with a 404, which is pruned below
+		result, _ := call(ctx, r, func(ctx context.Context) (sr.CompatibilityResult, error) {
+			result := r.client.Compatibility(ctx, subject)[0]
+			return result, result.Err
+		})
+		rawCompatibilityResults[i] = result
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	prunedCompatibilityResults := collections.Filter(rawCompatibilityResults, func(result sr.CompatibilityResult) bool {
+		return result.Err == nil || !client.IsNotFound(result.Err)
+	})
+
+	errs := make([]error, 0)
+	for _, result := range prunedCompatibilityResults {
+		if result.Err != nil {
+			errs = append(errs, result.Err)
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	globalCompatibility, err := call(ctx, r, func(ctx context.Context) (sr.CompatibilityResult, error) {
+		result := r.client.Compatibility(ctx)[0]
+		return result, result.Err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to retrieve global compatibility: %w", err)
+	}
+
+	globalMode, err := call(ctx, r, func(ctx context.Context) (sr.ModeResult, error) {
+		result := r.client.Mode(ctx)[0]
+		return result, result.Err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to retrieve global mode: %w", err)
+	}
+
+	rawModes := make([]sr.ModeResult, len(liveSubjects))
+	err = forEach(r.Concurrency, liveSubjects, func(i int, subject string) error {
+		// A subject without its own mode fails with a 404, which is pruned below
+		result, _ := call(ctx, r, func(ctx context.Context) (sr.ModeResult, error) {
+			result := r.client.Mode(ctx, subject)[0]
+			return result, result.Err
+		})
+		rawModes[i] = result
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	modes := collections.Filter(rawModes, func(result sr.ModeResult) bool {
+		return result.Err == nil || !client.IsNotFound(result.Err)
+	})
+	if err := client.ModeErrors(modes); err != nil {
+		return nil, fmt.Errorf("unable to retrieve subject modes: %w", err)
+	}
+
+	var result state.State
+	result.SubjectSchemas = subjectSchemas
+	result.CompatibilityResults = prunedCompatibilityResults
+	result.SoftDeletions = softDeletions
+	result.DeletedSubjects = slices.Sorted(maps.Keys(deletedSubjects))
+	result.GlobalCompatibility = &globalCompatibility
+	result.GlobalMode = &globalMode.Mode
+	result.Modes = modes
+
+	return &result, nil
+
+}
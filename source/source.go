@@ -0,0 +1,12 @@
+// Package source reads the state of a schema registry, from the registry
+// itself, its _schemas topic or an export
+package source
+
+import (
+	"context"
+	"go-schema-migrator/state"
+)
+
+type Source interface {
+	GetState(ctx context.Context) (*state.State, error)
+}
@@ -0,0 +1,281 @@
+package source
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/twmb/franz-go/pkg/kadm"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/sr"
+	"go-schema-migrator/client"
+	"go-schema-migrator/state"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+)
+
+type TopicSource struct {
+	Seed  string             `koanf:"seed"`
+	Topic string             `koanf:"topic"`
+	SASL  *client.SASLConfig `koanf:"sasl"`
+	TLS   *client.TLSConfig  `koanf:"tls"`
+	// IdleTimeout is how long to wait for another record before failing on
+	// partitions whose last offsets are never returned (compacted away, or
+	// aborted). Defaults to 10s.
+	IdleTimeout time.Duration `koanf:"idle_timeout"`
+}
+
+const defaultIdleTimeout = 10 * time.Second
+
+// topicState accumulates the latest value seen for each key, giving the same
+// result as reading the topic after compaction
+type topicState struct {
+	schemas         map[sr.SubjectVersion]client.TopicSchemaValue
+	configs         map[string]sr.CompatibilityResult
+	deletedSubjects map[string]int
+	hardDeletions   map[sr.SubjectVersion]int
+	modes           map[string]sr.Mode
+}
+
+func (t *topicState) apply(record *kgo.Record) error {
+	var key client.TopicKey
+	err := json.Unmarshal(record.Key, &key)
+	if err != nil {
+		return fmt.Errorf("unable to unmarshal key at offset %v: %w", record.Offset, err)
+	}
+
+	// A nil value is a tombstone, removing whatever the key previously held
+	tombstone := record.Value == nil
+
+	switch key.KeyType {
+	case "SCHEMA":
+		ref := sr.SubjectVersion{Subject: key.Subject, Version: key.Version}
+		if tombstone {
+			// Only a tombstone that follows the schema it deletes tells us the ID
+			if value, ok := t.schemas[ref]; ok {
+				t.hardDeletions[ref] = value.ID
+			}
+			delete(t.schemas, ref)
+			return nil
+		}
+		var value client.TopicSchemaValue
+		err = json.Unmarshal(record.Value, &value)
+		if err != nil {
+			return fmt.Errorf("unable to unmarshal schema value at offset %v: %w", record.Offset, err)
+		}
+		// To match what we get from REST
+		if len(value.References) == 0 {
+			value.References = nil
+		}
+		t.schemas[ref] = value
+		delete(t.hardDeletions, ref)
+	case "CONFIG":
+		if tombstone {
+			delete(t.configs, key.Subject)
+			return nil
+		}
+		var value sr.CompatibilityResult
+		err = json.Unmarshal(record.Value, &value)
+		if err != nil {
+			return fmt.Errorf("unable to unmarshal config value at offset %v: %w", record.Offset, err)
+		}
+		value.Subject = key.Subject
+		t.configs[key.Subject] = value
+	case "DELETE_SUBJECT":
+		if tombstone {
+			delete(t.deletedSubjects, key.Subject)
+			return nil
+		}
+		var value client.TopicDeleteSubjectValue
+		err = json.Unmarshal(record.Value, &value)
+		if err != nil {
+			return fmt.Errorf("unable to unmarshal delete subject value at offset %v: %w", record.Offset, err)
+		}
+		t.deletedSubjects[key.Subject] = value.Version
+	case "MODE":
+		if tombstone {
+			delete(t.modes, key.Subject)
+			return nil
+		}
+		var value client.TopicModeValue
+		err = json.Unmarshal(record.Value, &value)
+		if err != nil {
+			return fmt.Errorf("unable to unmarshal mode value at offset %v: %w", record.Offset, err)
+		}
+		t.modes[key.Subject] = value.Mode
+	}
+
+	return nil
+}
+
+func (t *topicState) toState() *state.State {
+	subjectSchemas := make([]sr.SubjectSchema, 0)
+	compatibilityResults := make([]sr.CompatibilityResult, 0)
+	softDeletions := make([]sr.SubjectVersion, 0)
+
+	refs := slices.SortedFunc(maps.Keys(t.schemas), func(a, b sr.SubjectVersion) int {
+		return cmp.Or(cmp.Compare(a.Subject, b.Subject), cmp.Compare(a.Version, b.Version))
+	})
+	for _, ref := range refs {
+		value := t.schemas[ref]
+		subjectSchemas = append(subjectSchemas, value.SubjectSchema)
+
+		// Deleting a subject soft deletes every version up to the one recorded
+		deletedVersion, subjectDeleted := t.deletedSubjects[ref.Subject]
+		if value.Deleted || (subjectDeleted && ref.Version <= deletedVersion) {
+			softDeletions = append(softDeletions, ref)
+		}
+	}
+
+	// The placeholders of reserved IDs are read back as the IDs they reserve
+	hardDeletions := make([]state.HardDeletion, 0)
+	reservedIDs := make([]int, 0)
+	refs = slices.SortedFunc(maps.Keys(t.hardDeletions), func(a, b sr.SubjectVersion) int {
+		return cmp.Or(cmp.Compare(a.Subject, b.Subject), cmp.Compare(a.Version, b.Version))
+	})
+	for _, ref := range refs {
+		deletion := state.HardDeletion{Subject: ref.Subject, Version: ref.Version, ID: t.hardDeletions[ref]}
+		if deletion.IsReservedID() {
+			reservedIDs = append(reservedIDs, deletion.ID)
+			continue
+		}
+		hardDeletions = append(hardDeletions, deletion)
+	}
+	slices.Sort(reservedIDs)
+
+	// The global compatibility level and mode are keyed without a subject
+	var globalCompatibility *sr.CompatibilityResult
+	for _, subject := range slices.Sorted(maps.Keys(t.configs)) {
+		result := t.configs[subject]
+		if subject == "" {
+			globalCompatibility = &result
+			continue
+		}
+		compatibilityResults = append(compatibilityResults, result)
+	}
+
+	var globalMode *sr.Mode
+	modes := make([]sr.ModeResult, 0)
+	for _, subject := range slices.Sorted(maps.Keys(t.modes)) {
+		mode := t.modes[subject]
+		if subject == "" {
+			globalMode = &mode
+			continue
+		}
+		modes = append(modes, sr.ModeResult{Subject: subject, Mode: mode})
+	}
+
+	var result state.State
+	result.SubjectSchemas = subjectSchemas
+	result.CompatibilityResults = compatibilityResults
+	result.SoftDeletions = softDeletions
+	result.DeletedSubjects = result.AllVersionsDeleted()
+	result.HardDeletions = hardDeletions
+	result.ReservedIDs = reservedIDs
+	result.GlobalCompatibility = globalCompatibility
+	result.GlobalMode = globalMode
+	result.Modes = modes
+
+	return &result
+}
+
+func (t *TopicSource) GetState(ctx context.Context) (*state.State, error) {
+	opts, err := client.KafkaOpts(t.Seed, t.SASL, t.TLS)
+	if err != nil {
+		return nil, err
+	}
+	opts = append(opts,
+		kgo.ConsumeTopics(t.Topic),
+		kgo.ConsumeResetOffset(kgo.NewOffset().AtStart()),
+		// Transaction markers take up offsets too, and show us we've passed them
+		kgo.KeepControlRecords(),
+	)
+
+	cl, err := kgo.NewClient(opts...)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create kafka client: %w", err)
+	}
+	defer cl.Close()
+
+	// Find the high watermark of each partition, so we know when to stop
+	endOffsets, err := kadm.NewClient(cl).ListEndOffsets(ctx, t.Topic)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list end offsets for %v: %w", t.Topic, err)
+	}
+	if err = endOffsets.Error(); err != nil {
+		return nil, fmt.Errorf("unable to list end offsets for %v: %w", t.Topic, err)
+	}
+	startOffsets, err := kadm.NewClient(cl).ListStartOffsets(ctx, t.Topic)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list start offsets for %v: %w", t.Topic, err)
+	}
+	if err = startOffsets.Error(); err != nil {
+		return nil, fmt.Errorf("unable to list start offsets for %v: %w", t.Topic, err)
+	}
+	remaining := make(map[int32]int64)
+	endOffsets.Each(func(offset kadm.ListedOffset) {
+		if offset.Offset > 0 {
+			remaining[offset.Partition] = offset.Offset
+		}
+	})
+	// The offset each partition has been read up to, to report where it stopped
+	current := make(map[int32]int64)
+	startOffsets.Each(func(offset kadm.ListedOffset) {
+		current[offset.Partition] = offset.Offset
+	})
+
+	state := topicState{
+		schemas:         make(map[sr.SubjectVersion]client.TopicSchemaValue),
+		configs:         make(map[string]sr.CompatibilityResult),
+		deletedSubjects: make(map[string]int),
+		hardDeletions:   make(map[sr.SubjectVersion]int),
+		modes:           make(map[string]sr.Mode),
+	}
+
+	idleTimeout := t.IdleTimeout
+	if idleTimeout <= 0 {
+		idleTimeout = defaultIdleTimeout
+	}
+
+	for len(remaining) > 0 {
+		pollCtx, cancel := context.WithTimeout(ctx, idleTimeout)
+		fetches := cl.PollFetches(pollCtx)
+		cancel()
+		err = fetches.Err()
+		if errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil {
+			// Nothing more has arrived, so the state read so far may be missing
+			// the records left before the end
+			partitions := make([]string, 0, len(remaining))
+			for _, partition := range slices.Sorted(maps.Keys(remaining)) {
+				partitions = append(partitions, fmt.Sprintf("partition %v at offset %v of end offset %v", partition, current[partition], remaining[partition]))
+			}
+			return nil, fmt.Errorf("unable to read %v to its end, as nothing arrived for %v: %v", t.Topic, idleTimeout, strings.Join(partitions, ", "))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("unable to consume from %v: %w", t.Topic, err)
+		}
+		iter := fetches.RecordIter()
+		for !iter.Done() {
+			record := iter.Next()
+			end, ok := remaining[record.Partition]
+			if !ok {
+				continue
+			}
+			current[record.Partition] = record.Offset + 1
+			if record.Offset < end && !record.Attrs.IsControl() {
+				err = state.apply(record)
+				if err != nil {
+					return nil, err
+				}
+			}
+			if record.Offset+1 >= end {
+				delete(remaining, record.Partition)
+			}
+		}
+	}
+
+	return state.toState(), nil
+}
@@ -0,0 +1,329 @@
+package source
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kadm"
+	"github.com/twmb/franz-go/pkg/kfake"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/kmsg"
+	"github.com/twmb/franz-go/pkg/sr"
+	"go-schema-migrator/client"
+	"go-schema-migrator/sink"
+	"go-schema-migrator/state"
+)
+
+func record(t *testing.T, keyType string, subject string, version int, value any) *kgo.Record {
+	t.Helper()
+	key, err := json.Marshal(client.TopicKey{KeyType: keyType, Subject: subject, Version: version})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &kgo.Record{Key: key}
+	if value != nil {
+		r.Value, err = json.Marshal(value)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return r
+}
+
+func schemaRecord(t *testing.T, subject string, version int, id int, deleted bool) *kgo.Record {
+	return record(t, "SCHEMA", subject, version, client.TopicSchemaValue{
+		SubjectSchema: sr.SubjectSchema{Subject: subject, Version: version, ID: id, Schema: sr.Schema{Schema: `"string"`}},
+		Deleted:       deleted,
+	})
+}
+
+func subjectSchema(subject string, version int, id int) sr.SubjectSchema {
+	return sr.SubjectSchema{Subject: subject, Version: version, ID: id, Schema: sr.Schema{Schema: `"string"`}}
+}
+
+func emptyState() *state.State {
+	return &state.State{
+		SubjectSchemas:       []sr.SubjectSchema{},
+		CompatibilityResults: []sr.CompatibilityResult{},
+		SoftDeletions:        []sr.SubjectVersion{},
+		DeletedSubjects:      []string{},
+		HardDeletions:        []state.HardDeletion{},
+		ReservedIDs:          []int{},
+		Modes:                []sr.ModeResult{},
+	}
+}
+
+func TestTopicStateApply(t *testing.T) {
+	tests := []struct {
+		name    string
+		records func(t *testing.T) []*kgo.Record
+		want    func(s *state.State)
+	}{
+		{
+			name: "schemas are sorted by subject and version",
+			records: func(t *testing.T) []*kgo.Record {
+				return []*kgo.Record{
+					schemaRecord(t, "b", 1, 3, false),
+					schemaRecord(t, "a", 2, 2, false),
+					schemaRecord(t, "a", 1, 1, false),
+				}
+			},
+			want: func(s *state.State) {
+				s.SubjectSchemas = []sr.SubjectSchema{subjectSchema("a", 1, 1), subjectSchema("a", 2, 2), subjectSchema("b", 1, 3)}
+			},
+		},
+		{
+			name: "the last record for a key wins",
+			records: func(t *testing.T) []*kgo.Record {
+				return []*kgo.Record{
+					schemaRecord(t, "a", 1, 1, false),
+					schemaRecord(t, "a", 1, 1, true),
+				}
+			},
+			want: func(s *state.State) {
+				s.SubjectSchemas = []sr.SubjectSchema{subjectSchema("a", 1, 1)}
+				s.SoftDeletions = []sr.SubjectVersion{{Subject: "a", Version: 1}}
+				s.DeletedSubjects = []string{"a"}
+			},
+		},
+		{
+			name: "a tombstone after its schema is a hard deletion",
+			records: func(t *testing.T) []*kgo.Record {
+				return []*kgo.Record{
+					schemaRecord(t, "a", 1, 1, true),
+					record(t, "SCHEMA", "a", 1, nil),
+				}
+			},
+			want: func(s *state.State) {
+				s.HardDeletions = []state.HardDeletion{{Subject: "a", Version: 1, ID: 1}}
+			},
+		},
+		{
+			name: "a tombstone without its schema is ignored",
+			records: func(t *testing.T) []*kgo.Record {
+				return []*kgo.Record{
+					record(t, "SCHEMA", "a", 1, nil),
+				}
+			},
+			want: func(s *state.State) {},
+		},
+		{
+			name: "re-registering after a tombstone undoes the hard deletion",
+			records: func(t *testing.T) []*kgo.Record {
+				return []*kgo.Record{
+					schemaRecord(t, "a", 1, 1, true),
+					record(t, "SCHEMA", "a", 1, nil),
+					schemaRecord(t, "a", 1, 2, false),
+				}
+			},
+			want: func(s *state.State) {
+				s.SubjectSchemas = []sr.SubjectSchema{subjectSchema("a", 1, 2)}
+			},
+		},
+		{
+			name: "placeholders of reserved IDs are read back as reserved IDs",
+			records: func(t *testing.T) []*kgo.Record {
+				return []*kgo.Record{
+					schemaRecord(t, "_reserved_id_7", 1, 7, true),
+					record(t, "SCHEMA", "_reserved_id_7", 1, nil),
+					schemaRecord(t, ":.ctx:_reserved_id_5", 1, 5, true),
+					record(t, "SCHEMA", ":.ctx:_reserved_id_5", 1, nil),
+				}
+			},
+			want: func(s *state.State) {
+				s.ReservedIDs = []int{5, 7}
+			},
+		},
+		{
+			name: "configs are keyed by subject, with the global level unkeyed",
+			records: func(t *testing.T) []*kgo.Record {
+				return []*kgo.Record{
+					record(t, "CONFIG", "", 0, sr.CompatibilityResult{Level: sr.CompatNone}),
+					record(t, "CONFIG", "a", 0, sr.CompatibilityResult{Level: sr.CompatFull}),
+					record(t, "CONFIG", "b", 0, sr.CompatibilityResult{Level: sr.CompatForward}),
+					record(t, "CONFIG", "b", 0, nil),
+					record(t, "CONFIG", "", 0, sr.CompatibilityResult{Level: sr.CompatBackward}),
+				}
+			},
+			want: func(s *state.State) {
+				s.CompatibilityResults = []sr.CompatibilityResult{{Subject: "a", Level: sr.CompatFull}}
+				s.GlobalCompatibility = &sr.CompatibilityResult{Level: sr.CompatBackward}
+			},
+		},
+		{
+			name: "deleting a subject soft deletes the versions up to the one named",
+			records: func(t *testing.T) []*kgo.Record {
+				return []*kgo.Record{
+					schemaRecord(t, "a", 1, 1, false),
+					schemaRecord(t, "a", 2, 2, false),
+					schemaRecord(t, "a", 3, 3, false),
+					record(t, "DELETE_SUBJECT", "a", 0, client.TopicDeleteSubjectValue{Subject: "a", Version: 2}),
+				}
+			},
+			want: func(s *state.State) {
+				s.SubjectSchemas = []sr.SubjectSchema{subjectSchema("a", 1, 1), subjectSchema("a", 2, 2), subjectSchema("a", 3, 3)}
+				s.SoftDeletions = []sr.SubjectVersion{{Subject: "a", Version: 1}, {Subject: "a", Version: 2}}
+			},
+		},
+		{
+			name: "a tombstone removes the subject deletion",
+			records: func(t *testing.T) []*kgo.Record {
+				return []*kgo.Record{
+					schemaRecord(t, "a", 1, 1, false),
+					record(t, "DELETE_SUBJECT", "a", 0, client.TopicDeleteSubjectValue{Subject: "a", Version: 1}),
+					record(t, "DELETE_SUBJECT", "a", 0, nil),
+				}
+			},
+			want: func(s *state.State) {
+				s.SubjectSchemas = []sr.SubjectSchema{subjectSchema("a", 1, 1)}
+			},
+		},
+		{
+			name: "modes are keyed by subject, with the global mode unkeyed",
+			records: func(t *testing.T) []*kgo.Record {
+				return []*kgo.Record{
+					record(t, "MODE", "", 0, client.TopicModeValue{Mode: sr.ModeImport}),
+					record(t, "MODE", "b", 0, client.TopicModeValue{Mode: sr.ModeReadOnly}),
+					record(t, "MODE", "a", 0, client.TopicModeValue{Mode: sr.ModeReadWrite}),
+					record(t, "MODE", "c", 0, client.TopicModeValue{Mode: sr.ModeReadOnly}),
+					record(t, "MODE", "c", 0, nil),
+					record(t, "MODE", "", 0, client.TopicModeValue{Mode: sr.ModeReadWrite}),
+				}
+			},
+			want: func(s *state.State) {
+				s.Modes = []sr.ModeResult{{Subject: "a", Mode: sr.ModeReadWrite}, {Subject: "b", Mode: sr.ModeReadOnly}}
+				mode := sr.ModeReadWrite
+				s.GlobalMode = &mode
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := topicState{
+				schemas:         make(map[sr.SubjectVersion]client.TopicSchemaValue),
+				configs:         make(map[string]sr.CompatibilityResult),
+				deletedSubjects: make(map[string]int),
+				hardDeletions:   make(map[sr.SubjectVersion]int),
+				modes:           make(map[string]sr.Mode),
+			}
+			for i, r := range tt.records(t) {
+				r.Offset = int64(i)
+				if err := ts.apply(r); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want := emptyState()
+			tt.want(want)
+			if got := ts.toState(); !reflect.DeepEqual(got, want) {
+				t.Errorf("got %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestTopicStateApplyInvalidKey(t *testing.T) {
+	ts := topicState{}
+	err := ts.apply(&kgo.Record{Key: []byte("not json"), Offset: 4})
+	if err == nil {
+		t.Fatal("expected an error for a key that isn't json")
+	}
+}
+
+func newClient(t *testing.T, seed string) *kgo.Client {
+	t.Helper()
+	cl, err := kgo.NewClient(kgo.SeedBrokers(seed))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(cl.Close)
+	return cl
+}
+
+// TestTopicSourceRoundTrip writes a state with the topic sink, checks the
+// topic source reads back the same state, and that it fails when the last
+// offset before the end never arrives
+func TestTopicSourceRoundTrip(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	cluster, err := kfake.NewCluster(kfake.NumBrokers(1), kfake.SeedTopics(1, "_schemas"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cluster.Close()
+	seed := cluster.ListenAddrs()[0]
+
+	// The sink may change the state it's given, so it gets a copy of its own
+	roundTripState := func() *state.State {
+		mode := sr.ModeReadWrite
+		s := emptyState()
+		s.SubjectSchemas = []sr.SubjectSchema{subjectSchema("a", 1, 1), subjectSchema("a", 2, 2), subjectSchema("b", 1, 4)}
+		s.SoftDeletions = []sr.SubjectVersion{{Subject: "b", Version: 1}}
+		s.DeletedSubjects = []string{"b"}
+		s.HardDeletions = []state.HardDeletion{{Subject: "a", Version: 3, ID: 3}}
+		s.ReservedIDs = []int{9}
+		s.CompatibilityResults = []sr.CompatibilityResult{{Subject: "a", Level: sr.CompatFull}}
+		s.GlobalCompatibility = &sr.CompatibilityResult{Level: sr.CompatBackward}
+		s.Modes = []sr.ModeResult{{Subject: "a", Mode: sr.ModeReadOnly}}
+		s.GlobalMode = &mode
+		return s
+	}
+
+	snk := &sink.TopicSink{Seed: seed, Topic: "_schemas"}
+	if err = snk.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	if err = snk.PutState(ctx, roundTripState()); err != nil {
+		t.Fatal(err)
+	}
+
+	src := &TopicSource{Seed: seed, Topic: "_schemas", IdleTimeout: 500 * time.Millisecond}
+	got, err := src.GetState(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := roundTripState(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	// Report an end offset one past the last record, as for a topic whose last
+	// offset was compacted away or aborted, so that it never arrives
+	ends, err := kadm.NewClient(newClient(t, seed)).ListEndOffsets(ctx, "_schemas")
+	if err != nil {
+		t.Fatal(err)
+	}
+	end, _ := ends.Lookup("_schemas", 0)
+	cluster.ControlKey(int16(kmsg.ListOffsets), func(req kmsg.Request) (kmsg.Response, error, bool) {
+		cluster.KeepControl()
+		listReq := req.(*kmsg.ListOffsetsRequest)
+		resp := listReq.ResponseKind().(*kmsg.ListOffsetsResponse)
+		for _, reqTopic := range listReq.Topics {
+			topic := kmsg.NewListOffsetsResponseTopic()
+			topic.Topic = reqTopic.Topic
+			for _, reqPartition := range reqTopic.Partitions {
+				partition := kmsg.NewListOffsetsResponseTopicPartition()
+				partition.Partition = reqPartition.Partition
+				partition.Offset = 0
+				if reqPartition.Timestamp == -1 {
+					partition.Offset = end.Offset + 1
+				}
+				partition.LeaderEpoch = -1
+				topic.Partitions = append(topic.Partitions, partition)
+			}
+			resp.Topics = append(resp.Topics, topic)
+		}
+		return resp, nil, true
+	})
+
+	_, err = src.GetState(ctx)
+	want := fmt.Sprintf("partition 0 at offset %v of end offset %v", end.Offset, end.Offset+1)
+	if err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("got %v, want an error containing %q", err, want)
+	}
+}
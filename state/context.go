@@ -0,0 +1,67 @@
+package state
+
+import (
+	"fmt"
+	"github.com/twmb/franz-go/pkg/sr"
+	"strings"
+)
+
+// DefaultContext is the context that unqualified subjects belong to
+const DefaultContext = "."
+
+// NormalizeContext returns the context with its leading dot, so that "orders"
+// and ".orders" name the same context
+func NormalizeContext(context string) string {
+	if context == "" || context == DefaultContext {
+		return DefaultContext
+	}
+	if !strings.HasPrefix(context, ".") {
+		return "." + context
+	}
+	return context
+}
+
+// SplitContext splits a subject such as ":.orders:orders-value" into its
+// context and unqualified name. Unqualified subjects are in the default context.
+func SplitContext(subject string) (string, string) {
+	if strings.HasPrefix(subject, ":.") {
+		context, name, found := strings.Cut(subject[1:], ":")
+		if found {
+			return NormalizeContext(context), name
+		}
+	}
+	return DefaultContext, subject
+}
+
+// Qualify returns the subject name within the given context, leaving subjects
+// in the default context unqualified
+func Qualify(context string, name string) string {
+	context = NormalizeContext(context)
+	if context == DefaultContext {
+		return name
+	}
+	return fmt.Sprintf(":%v:%v", context, name)
+}
+
+// SubjectContext returns the context that a subject belongs to
+func SubjectContext(subject string) string {
+	context, _ := SplitContext(subject)
+	return context
+}
+
+// ContextID identifies a schema ID, which is only unique within a context
+type ContextID struct {
+	Context string
+	ID      int
+}
+
+func GetContextID(subjectSchema sr.SubjectSchema) ContextID {
+	return ContextID{Context: SubjectContext(subjectSchema.Subject), ID: subjectSchema.ID}
+}
+
+// IsContextSubject reports whether the subject names a context itself, such
+// as ":.orders:", which is how the configuration of a context is addressed
+func IsContextSubject(subject string) bool {
+	_, name := SplitContext(subject)
+	return strings.HasPrefix(subject, ":.") && name == ""
+}
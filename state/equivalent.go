@@ -0,0 +1,79 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// prune removes empty values from decoded JSON, so that values that only
+// differ in how they represent nothing (null, empty or missing) are the same
+func prune(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		result := make(map[string]any)
+		for key, element := range v {
+			if pruned := prune(element); pruned != nil {
+				result[key] = pruned
+			}
+		}
+		if len(result) == 0 {
+			return nil
+		}
+		return result
+	case []any:
+		if len(v) == 0 {
+			return nil
+		}
+		result := make([]any, 0, len(v))
+		for _, element := range v {
+			result = append(result, prune(element))
+		}
+		return result
+	case string:
+		if v == "" {
+			return nil
+		}
+	case bool:
+		if !v {
+			return nil
+		}
+	}
+	return value
+}
+
+// Canonical returns a structured value such as metadata marshalled with its
+// empty values removed, so that equivalent values give the same string
+func Canonical(value any) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	var decoded any
+	err = json.Unmarshal(data, &decoded)
+	if err != nil {
+		return string(data)
+	}
+	data, _ = json.Marshal(prune(decoded))
+	return string(data)
+}
+
+// Equivalent reports whether two structured values such as metadata are the
+// same once marshalled, ignoring empty values
+func Equivalent(a any, b any) bool {
+	decode := func(value any) (any, error) {
+		data, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		var decoded any
+		err = json.Unmarshal(data, &decoded)
+		return prune(decoded), err
+	}
+	aDecoded, aErr := decode(a)
+	bDecoded, bErr := decode(b)
+	if aErr != nil || bErr != nil {
+		return reflect.DeepEqual(a, b)
+	}
+	return reflect.DeepEqual(aDecoded, bDecoded)
+}
@@ -0,0 +1,38 @@
+package state
+
+import (
+	"fmt"
+	"github.com/twmb/franz-go/pkg/sr"
+	"strings"
+)
+
+func GetReference(subjectSchema sr.SubjectSchema) sr.SubjectVersion {
+	return sr.SubjectVersion{Subject: subjectSchema.Subject, Version: subjectSchema.Version}
+}
+
+// ResolveSubject returns the subject that a reference made from referrer
+// points to. An unqualified reference is to a subject in the referrer's context.
+func ResolveSubject(referrer string, subject string) string {
+	if strings.HasPrefix(subject, ":.") {
+		context, name := SplitContext(subject)
+		return Qualify(context, name)
+	}
+	return Qualify(SubjectContext(referrer), subject)
+}
+
+// ResolveReference returns the subject version that a schema reference points to
+func ResolveReference(subjectSchema sr.SubjectSchema, schemaReference sr.SchemaReference) sr.SubjectVersion {
+	return sr.SubjectVersion{Subject: ResolveSubject(subjectSchema.Subject, schemaReference.Subject), Version: schemaReference.Version}
+}
+
+// mapReference rewrites a reference made from referrer, now moved to mapped,
+// so that it points to where fn moves its target. A reference that was
+// unqualified stays unqualified if its target is still in the same context.
+func mapReference(referrer string, mapped string, subject string, fn func(string) string) string {
+	target := fn(ResolveSubject(referrer, subject))
+	context, name := SplitContext(target)
+	if !strings.HasPrefix(subject, ":.") && context == SubjectContext(mapped) {
+		return name
+	}
+	return fmt.Sprintf(":%v:%v", context, name)
+}
@@ -0,0 +1,478 @@
+package state
+
+import (
+	"container/heap"
+	"fmt"
+	"github.com/twmb/franz-go/pkg/sr"
+	"go-schema-migrator/internal/collections"
+	"go-schema-migrator/validation"
+	"maps"
+	"slices"
+	"strings"
+)
+
+type State struct {
+	SubjectSchemas       []sr.SubjectSchema       `yaml:"subjectSchemas"`
+	CompatibilityResults []sr.CompatibilityResult `yaml:"compatibilityResults"`
+	SoftDeletions        []sr.SubjectVersion      `yaml:"softDeletions"`
+	DeletedSubjects      []string                 `yaml:"deletedSubjects"`
+	HardDeletions        []HardDeletion           `yaml:"hardDeletions"`
+	ReservedIDs          []int                    `yaml:"reservedIds"`
+	GlobalCompatibility  *sr.CompatibilityResult  `yaml:"globalCompatibility"`
+	GlobalMode           *sr.Mode                 `yaml:"globalMode"`
+	Modes                []sr.ModeResult          `yaml:"modes"`
+}
+
+// HardDeletion records a subject version that has been permanently deleted. The
+// schema no longer exists, but consumers may still hold its ID.
+type HardDeletion struct {
+	Subject string `yaml:"subject"`
+	Version int    `yaml:"version"`
+	ID      int    `yaml:"id"`
+}
+
+// HardDeletionsToReplay returns the hard deletions whose ID isn't still in use
+// by a live schema, and so needs reserving separately in the target
+func (s *State) HardDeletionsToReplay() []HardDeletion {
+	ids := make(map[ContextID]bool)
+	for _, subjectSchema := range s.SubjectSchemas {
+		ids[GetContextID(subjectSchema)] = true
+	}
+	return collections.Filter(s.HardDeletions, func(deletion HardDeletion) bool {
+		return !ids[ContextID{Context: SubjectContext(deletion.Subject), ID: deletion.ID}]
+	})
+}
+
+// reservedIDPrefix names the subjects that reserved IDs are replayed under
+const reservedIDPrefix = "_reserved_id_"
+
+// ReservedIDsToReplay returns a placeholder deletion for each reserved ID that
+// isn't already in use in the given context, by a live schema or a replayed
+// hard deletion. Each is under a subject of its own, so that the target skips
+// the ID just as it skips the ID of a hard deletion.
+func (s *State) ReservedIDsToReplay(context string) []HardDeletion {
+	context = NormalizeContext(context)
+	used := make(map[int]bool)
+	for _, subjectSchema := range s.SubjectSchemas {
+		if SubjectContext(subjectSchema.Subject) == context {
+			used[subjectSchema.ID] = true
+		}
+	}
+	for _, deletion := range s.HardDeletionsToReplay() {
+		if SubjectContext(deletion.Subject) == context {
+			used[deletion.ID] = true
+		}
+	}
+	result := make([]HardDeletion, 0)
+	for _, id := range s.ReservedIDs {
+		if used[id] {
+			continue
+		}
+		used[id] = true
+		result = append(result, HardDeletion{Subject: Qualify(context, fmt.Sprintf("%v%v", reservedIDPrefix, id)), Version: 1, ID: id})
+	}
+	return result
+}
+
+// IsReservedID reports whether the deletion is the placeholder of a reserved
+// ID, rather than of a schema that was deleted
+func (h HardDeletion) IsReservedID() bool {
+	_, name := SplitContext(h.Subject)
+	return name == fmt.Sprintf("%v%v", reservedIDPrefix, h.ID) && h.Version == 1
+}
+
+// PlaceholderSchema is registered in place of a hard deleted schema, so that
+// the target moves its ID counter past it before the deletion is applied
+func (h HardDeletion) PlaceholderSchema() sr.Schema {
+	return sr.Schema{
+		Schema: fmt.Sprintf(`{"type":"record","name":"HardDeleted%v","fields":[]}`, h.ID),
+		Type:   sr.TypeAvro,
+	}
+}
+
+// Validate checks that the state is self-consistent, reporting every problem
+// found rather than stopping at the first
+func (s *State) Validate() *validation.Report {
+	report := validation.NewReport()
+
+	all := make(map[sr.SubjectVersion]bool)
+	subjects := make(map[string]bool)
+	for _, subjectSchema := range s.SubjectSchemas {
+		all[GetReference(subjectSchema)] = true
+		subjects[subjectSchema.Subject] = true
+	}
+
+	index := make(map[sr.SubjectVersion]int)
+	ids := make(map[ContextID]sr.SubjectSchema)
+	for i, subjectSchema := range s.SubjectSchemas {
+		for _, schemaReference := range subjectSchema.References {
+			reference := ResolveReference(subjectSchema, schemaReference)
+			_, ok := index[reference]
+			if !ok {
+				if all[reference] {
+					report.Errorf("reference_order", subjectSchema.Subject, subjectSchema.Version, "reference to subject %v version %v appears later in the state", reference.Subject, reference.Version)
+				} else {
+					report.Errorf("missing_reference", subjectSchema.Subject, subjectSchema.Version, "reference to subject %v version %v not found", reference.Subject, reference.Version)
+				}
+			}
+		}
+		reference := GetReference(subjectSchema)
+		_, ok := index[reference]
+		if ok {
+			report.Errorf("duplicate_subject_version", subjectSchema.Subject, subjectSchema.Version, "subject version appears more than once")
+			continue
+		}
+		index[reference] = i
+
+		// Schema IDs are only unique within a context
+		existing, ok := ids[GetContextID(subjectSchema)]
+		if !ok {
+			ids[GetContextID(subjectSchema)] = subjectSchema
+		} else if existing.Schema.Schema != subjectSchema.Schema.Schema || existing.Type != subjectSchema.Type {
+			report.Errorf("conflicting_id", subjectSchema.Subject, subjectSchema.Version, "schema ID %v is also used by subject %v version %v with a different schema", subjectSchema.ID, existing.Subject, existing.Version)
+		} else if !Equivalent(existing.SchemaMetadata, subjectSchema.SchemaMetadata) {
+			// The registry keeps metadata and rule sets with the schema ID
+			report.Errorf("conflicting_id", subjectSchema.Subject, subjectSchema.Version, "schema ID %v is also used by subject %v version %v with different metadata", subjectSchema.ID, existing.Subject, existing.Version)
+		} else if !Equivalent(existing.SchemaRuleSet, subjectSchema.SchemaRuleSet) {
+			report.Errorf("conflicting_id", subjectSchema.Subject, subjectSchema.Version, "schema ID %v is also used by subject %v version %v with a different rule set", subjectSchema.ID, existing.Subject, existing.Version)
+		}
+	}
+
+	for _, deletion := range s.SoftDeletions {
+		if !all[deletion] {
+			report.Warnf("unknown_soft_deletion", deletion.Subject, deletion.Version, "soft deletion does not match any subject version")
+		}
+	}
+
+	// A soft deleted subject has every one of its versions soft deleted
+	softDeletions := collections.ToMap(s.SoftDeletions)
+	for _, subject := range s.DeletedSubjects {
+		if !subjects[subject] {
+			report.Warnf("unknown_deleted_subject", subject, 0, "subject deletion does not match any subject")
+			continue
+		}
+		for _, subjectSchema := range s.SubjectSchemas {
+			if subjectSchema.Subject == subject && !softDeletions[GetReference(subjectSchema)] {
+				report.Errorf("live_version_of_deleted_subject", subject, subjectSchema.Version, "subject is deleted but this version is not soft deleted")
+			}
+		}
+	}
+
+	for _, deletion := range s.HardDeletions {
+		if all[sr.SubjectVersion{Subject: deletion.Subject, Version: deletion.Version}] {
+			report.Errorf("hard_deleted_version_exists", deletion.Subject, deletion.Version, "subject version is hard deleted but still has a schema")
+		}
+	}
+
+	reservedIDs := collections.ToMap(s.ReservedIDs)
+	for _, subjectSchema := range s.SubjectSchemas {
+		if reservedIDs[subjectSchema.ID] {
+			report.Errorf("reserved_id_in_use", subjectSchema.Subject, subjectSchema.Version, "schema ID %v is reserved and must not be reused", subjectSchema.ID)
+		}
+	}
+
+	for _, result := range s.CompatibilityResults {
+		if !subjects[result.Subject] && !IsContextSubject(result.Subject) {
+			report.Warnf("unknown_compatibility_subject", result.Subject, 0, "compatibility level %v set for a subject with no schemas", result.Level)
+		}
+	}
+
+	for _, result := range s.Modes {
+		if !subjects[result.Subject] && !IsContextSubject(result.Subject) {
+			report.Warnf("unknown_mode_subject", result.Subject, 0, "mode %v set for a subject with no schemas", result.Mode)
+		}
+	}
+
+	return report
+}
+
+// AllVersionsDeleted returns the subjects whose every version is soft deleted,
+// which a registry treats as the subject itself being soft deleted
+func (s *State) AllVersionsDeleted() []string {
+	softDeletions := collections.ToMap(s.SoftDeletions)
+	deleted := make(map[string]bool)
+	for _, subjectSchema := range s.SubjectSchemas {
+		ref := GetReference(subjectSchema)
+		if _, ok := deleted[ref.Subject]; !ok {
+			deleted[ref.Subject] = true
+		}
+		deleted[ref.Subject] = deleted[ref.Subject] && softDeletions[ref]
+	}
+	subjects := make([]string, 0)
+	for subject, allDeleted := range deleted {
+		if allDeleted {
+			subjects = append(subjects, subject)
+		}
+	}
+	slices.Sort(subjects)
+	return subjects
+}
+
+// Subjects returns every subject named anywhere in the state, in order
+func (s *State) Subjects() []string {
+	subjects := make(map[string]bool)
+	for _, subjectSchema := range s.SubjectSchemas {
+		subjects[subjectSchema.Subject] = true
+	}
+	for _, deletion := range s.SoftDeletions {
+		subjects[deletion.Subject] = true
+	}
+	for _, subject := range s.DeletedSubjects {
+		subjects[subject] = true
+	}
+	for _, deletion := range s.HardDeletions {
+		subjects[deletion.Subject] = true
+	}
+	for _, result := range s.CompatibilityResults {
+		subjects[result.Subject] = true
+	}
+	for _, result := range s.Modes {
+		subjects[result.Subject] = true
+	}
+	return slices.Sorted(maps.Keys(subjects))
+}
+
+// MapSubjects returns a copy of the state with every subject, including those
+// named by references, replaced by the result of fn
+func (s *State) MapSubjects(fn func(string) string) *State {
+	result := *s
+
+	result.SubjectSchemas = make([]sr.SubjectSchema, 0, len(s.SubjectSchemas))
+	for _, subjectSchema := range s.SubjectSchemas {
+		mapped := fn(subjectSchema.Subject)
+		if subjectSchema.References != nil {
+			references := make([]sr.SchemaReference, 0, len(subjectSchema.References))
+			for _, schemaReference := range subjectSchema.References {
+				schemaReference.Subject = mapReference(subjectSchema.Subject, mapped, schemaReference.Subject, fn)
+				references = append(references, schemaReference)
+			}
+			subjectSchema.References = references
+		}
+		subjectSchema.Subject = mapped
+		result.SubjectSchemas = append(result.SubjectSchemas, subjectSchema)
+	}
+
+	result.SoftDeletions = make([]sr.SubjectVersion, 0, len(s.SoftDeletions))
+	for _, deletion := range s.SoftDeletions {
+		result.SoftDeletions = append(result.SoftDeletions, sr.SubjectVersion{Subject: fn(deletion.Subject), Version: deletion.Version})
+	}
+
+	result.DeletedSubjects = make([]string, 0, len(s.DeletedSubjects))
+	for _, subject := range s.DeletedSubjects {
+		result.DeletedSubjects = append(result.DeletedSubjects, fn(subject))
+	}
+
+	result.HardDeletions = make([]HardDeletion, 0, len(s.HardDeletions))
+	for _, deletion := range s.HardDeletions {
+		deletion.Subject = fn(deletion.Subject)
+		result.HardDeletions = append(result.HardDeletions, deletion)
+	}
+
+	result.CompatibilityResults = make([]sr.CompatibilityResult, 0, len(s.CompatibilityResults))
+	for _, compatibilityResult := range s.CompatibilityResults {
+		compatibilityResult.Subject = fn(compatibilityResult.Subject)
+		result.CompatibilityResults = append(result.CompatibilityResults, compatibilityResult)
+	}
+
+	result.Modes = make([]sr.ModeResult, 0, len(s.Modes))
+	for _, modeResult := range s.Modes {
+		modeResult.Subject = fn(modeResult.Subject)
+		result.Modes = append(result.Modes, modeResult)
+	}
+
+	return &result
+}
+
+// ReferencedBy returns every subject version in the state that the given
+// schemas reference, whether directly or through other schemas
+func (s *State) ReferencedBy(subjectSchemas []sr.SubjectSchema) map[sr.SubjectVersion]bool {
+	index := make(map[sr.SubjectVersion]sr.SubjectSchema)
+	for _, subjectSchema := range s.SubjectSchemas {
+		index[GetReference(subjectSchema)] = subjectSchema
+	}
+
+	referenced := make(map[sr.SubjectVersion]bool)
+	pending := slices.Clone(subjectSchemas)
+	for len(pending) > 0 {
+		subjectSchema := pending[0]
+		pending = pending[1:]
+		for _, schemaReference := range subjectSchema.References {
+			reference := ResolveReference(subjectSchema, schemaReference)
+			dependency, ok := index[reference]
+			if !ok || referenced[reference] {
+				continue
+			}
+			referenced[reference] = true
+			pending = append(pending, dependency)
+		}
+	}
+	return referenced
+}
+
+// IDs returns every schema ID named anywhere in the state, by context and in order
+func (s *State) IDs() []ContextID {
+	ids := make(map[ContextID]bool)
+	for _, subjectSchema := range s.SubjectSchemas {
+		ids[GetContextID(subjectSchema)] = true
+	}
+	for _, deletion := range s.HardDeletions {
+		ids[ContextID{Context: SubjectContext(deletion.Subject), ID: deletion.ID}] = true
+	}
+	for _, id := range s.ReservedIDs {
+		ids[ContextID{Context: DefaultContext, ID: id}] = true
+	}
+	return slices.SortedFunc(maps.Keys(ids), func(a, b ContextID) int {
+		if comparison := strings.Compare(a.Context, b.Context); comparison != 0 {
+			return comparison
+		}
+		return a.ID - b.ID
+	})
+}
+
+// MapIDs returns a copy of the state with every schema ID replaced by the
+// result of fn. Reserved IDs are treated as being in the default context.
+func (s *State) MapIDs(fn func(ContextID) int) *State {
+	result := *s
+
+	result.SubjectSchemas = make([]sr.SubjectSchema, 0, len(s.SubjectSchemas))
+	for _, subjectSchema := range s.SubjectSchemas {
+		subjectSchema.ID = fn(GetContextID(subjectSchema))
+		result.SubjectSchemas = append(result.SubjectSchemas, subjectSchema)
+	}
+
+	result.HardDeletions = make([]HardDeletion, 0, len(s.HardDeletions))
+	for _, deletion := range s.HardDeletions {
+		deletion.ID = fn(ContextID{Context: SubjectContext(deletion.Subject), ID: deletion.ID})
+		result.HardDeletions = append(result.HardDeletions, deletion)
+	}
+
+	result.ReservedIDs = make([]int, 0, len(s.ReservedIDs))
+	for _, id := range s.ReservedIDs {
+		result.ReservedIDs = append(result.ReservedIDs, fn(ContextID{Context: DefaultContext, ID: id}))
+	}
+
+	return &result
+}
+
+func compareSubjectSchemas(a, b sr.SubjectSchema) int {
+	comparison := strings.Compare(SubjectContext(a.Subject), SubjectContext(b.Subject))
+	if comparison != 0 {
+		return comparison
+	}
+	comparison = a.ID - b.ID
+	if comparison != 0 {
+		return comparison
+	}
+	comparison = a.Version - b.Version
+	return comparison
+}
+
+// subjectSchemaHeap holds the indexes of subject schemas whose references have
+// all been placed, with the lowest context, schema ID and version on top
+type subjectSchemaHeap struct {
+	indexes        []int
+	subjectSchemas []sr.SubjectSchema
+}
+
+func (h *subjectSchemaHeap) Len() int { return len(h.indexes) }
+func (h *subjectSchemaHeap) Less(i, j int) bool {
+	return compareSubjectSchemas(h.subjectSchemas[h.indexes[i]], h.subjectSchemas[h.indexes[j]]) < 0
+}
+func (h *subjectSchemaHeap) Swap(i, j int) { h.indexes[i], h.indexes[j] = h.indexes[j], h.indexes[i] }
+func (h *subjectSchemaHeap) Push(x any)    { h.indexes = append(h.indexes, x.(int)) }
+func (h *subjectSchemaHeap) Pop() any {
+	last := h.indexes[len(h.indexes)-1]
+	h.indexes = h.indexes[:len(h.indexes)-1]
+	return last
+}
+
+// Sort orders subject schemas so that every schema comes after the schemas it
+// references, using context, schema ID and version to order schemas that are
+// otherwise independent. Each context's schemas are grouped together, except
+// where a schema references one in another context, which can pull it ahead
+// of its own context. References to subject versions outside the state are
+// ignored here and reported by validate.
+func (s *State) Sort() error {
+	index := make(map[sr.SubjectVersion]int)
+	for i, subjectSchema := range s.SubjectSchemas {
+		ref := GetReference(subjectSchema)
+		if _, ok := index[ref]; !ok {
+			index[ref] = i
+		}
+	}
+
+	// dependencies[i] lists the schemas that i references, dependents[i] the
+	// schemas that reference i
+	dependencies := make([][]int, len(s.SubjectSchemas))
+	dependents := make([][]int, len(s.SubjectSchemas))
+	pending := make([]int, len(s.SubjectSchemas))
+	for i, subjectSchema := range s.SubjectSchemas {
+		for _, schemaReference := range subjectSchema.References {
+			j, ok := index[ResolveReference(subjectSchema, schemaReference)]
+			if !ok {
+				continue
+			}
+			dependencies[i] = append(dependencies[i], j)
+			dependents[j] = append(dependents[j], i)
+			pending[i]++
+		}
+	}
+
+	ready := &subjectSchemaHeap{subjectSchemas: s.SubjectSchemas}
+	for i := range s.SubjectSchemas {
+		if pending[i] == 0 {
+			ready.indexes = append(ready.indexes, i)
+		}
+	}
+	heap.Init(ready)
+
+	sorted := make([]sr.SubjectSchema, 0, len(s.SubjectSchemas))
+	for ready.Len() > 0 {
+		i := heap.Pop(ready).(int)
+		sorted = append(sorted, s.SubjectSchemas[i])
+		for _, j := range dependents[i] {
+			pending[j]--
+			if pending[j] == 0 {
+				heap.Push(ready, j)
+			}
+		}
+	}
+
+	if len(sorted) != len(s.SubjectSchemas) {
+		return fmt.Errorf("reference cycle found: %v", s.findCycle(dependencies, pending))
+	}
+
+	s.SubjectSchemas = sorted
+	return nil
+}
+
+// findCycle follows references from a schema that could not be placed until
+// a schema repeats, and describes the loop that was found
+func (s *State) findCycle(dependencies [][]int, pending []int) string {
+	start := slices.IndexFunc(pending, func(p int) bool { return p > 0 })
+
+	position := make(map[int]int)
+	path := make([]int, 0)
+	current := start
+	for {
+		if p, ok := position[current]; ok {
+			path = append(path[p:], current)
+			break
+		}
+		position[current] = len(path)
+		path = append(path, current)
+		// Every unplaced schema has at least one unplaced dependency
+		for _, j := range dependencies[current] {
+			if pending[j] > 0 {
+				current = j
+				break
+			}
+		}
+	}
+
+	steps := make([]string, 0, len(path))
+	for _, i := range path {
+		steps = append(steps, fmt.Sprintf("%v/%v", s.SubjectSchemas[i].Subject, s.SubjectSchemas[i].Version))
+	}
+	return strings.Join(steps, " -> ")
+}
@@ -0,0 +1,219 @@
+package state
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/twmb/franz-go/pkg/sr"
+)
+
+func schema(subject string, version int, id int, references ...sr.SchemaReference) sr.SubjectSchema {
+	return sr.SubjectSchema{Subject: subject, Version: version, ID: id, Schema: sr.Schema{Schema: `"string"`, References: references}}
+}
+
+func reference(subject string, version int) sr.SchemaReference {
+	return sr.SchemaReference{Name: subject, Subject: subject, Version: version}
+}
+
+func order(subjectSchemas []sr.SubjectSchema) []string {
+	result := make([]string, 0, len(subjectSchemas))
+	for _, subjectSchema := range subjectSchemas {
+		result = append(result, fmt.Sprintf("%v/%v", subjectSchema.Subject, subjectSchema.Version))
+	}
+	return result
+}
+
+func TestSort(t *testing.T) {
+	tests := []struct {
+		name           string
+		subjectSchemas []sr.SubjectSchema
+		want           []string
+	}{
+		{
+			name: "independent schemas are ordered by ID",
+			subjectSchemas: []sr.SubjectSchema{
+				schema("c", 1, 3),
+				schema("a", 1, 1),
+				schema("b", 1, 2),
+			},
+			want: []string{"a/1", "b/1", "c/1"},
+		},
+		{
+			name: "ties on ID are broken by version",
+			subjectSchemas: []sr.SubjectSchema{
+				schema("b", 2, 1),
+				schema("a", 3, 1),
+				schema("c", 1, 1),
+			},
+			want: []string{"c/1", "b/2", "a/3"},
+		},
+		{
+			name: "a referenced schema with a higher ID comes first",
+			subjectSchemas: []sr.SubjectSchema{
+				schema("a", 1, 1, reference("c", 1)),
+				schema("b", 1, 2),
+				schema("c", 1, 3),
+			},
+			want: []string{"b/1", "c/1", "a/1"},
+		},
+		{
+			name: "references are followed transitively",
+			subjectSchemas: []sr.SubjectSchema{
+				schema("a", 1, 1, reference("b", 1)),
+				schema("b", 1, 2, reference("c", 1)),
+				schema("c", 1, 3),
+			},
+			want: []string{"c/1", "b/1", "a/1"},
+		},
+		{
+			name: "schemas are grouped by context",
+			subjectSchemas: []sr.SubjectSchema{
+				schema(":.b:x", 1, 1),
+				schema("y", 1, 2),
+				schema(":.a:z", 1, 3),
+				schema("w", 1, 1),
+			},
+			want: []string{"w/1", "y/1", ":.a:z/1", ":.b:x/1"},
+		},
+		{
+			name: "a reference resolves within the referrer's context",
+			subjectSchemas: []sr.SubjectSchema{
+				schema(":.a:x", 1, 1, reference("y", 1)),
+				schema(":.a:y", 1, 2),
+				schema("y", 1, 3),
+			},
+			want: []string{"y/1", ":.a:y/1", ":.a:x/1"},
+		},
+		{
+			name: "a reference across contexts pulls a schema out of its context",
+			subjectSchemas: []sr.SubjectSchema{
+				schema(":.a:x", 1, 1, reference(":.b:y", 1)),
+				schema(":.a:z", 1, 2),
+				schema(":.b:y", 1, 1),
+			},
+			want: []string{":.a:z/1", ":.b:y/1", ":.a:x/1"},
+		},
+		{
+			name: "references outside the state are ignored",
+			subjectSchemas: []sr.SubjectSchema{
+				schema("b", 1, 2),
+				schema("a", 1, 1, reference("missing", 1), reference("b", 7)),
+			},
+			want: []string{"a/1", "b/1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &State{SubjectSchemas: tt.subjectSchemas}
+			if err := s.Sort(); err != nil {
+				t.Fatal(err)
+			}
+			if got := order(s.SubjectSchemas); !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSortCycle(t *testing.T) {
+	tests := []struct {
+		name           string
+		subjectSchemas []sr.SubjectSchema
+		want           string
+	}{
+		{
+			name: "a schema referencing itself",
+			subjectSchemas: []sr.SubjectSchema{
+				schema("a", 1, 1, reference("a", 1)),
+			},
+			want: "reference cycle found: a/1 -> a/1",
+		},
+		{
+			name: "the path is reported from where the loop starts",
+			subjectSchemas: []sr.SubjectSchema{
+				schema("a", 1, 1, reference("b", 1)),
+				schema("b", 1, 2, reference("c", 1)),
+				schema("c", 1, 3, reference("d", 1)),
+				schema("d", 1, 4, reference("b", 1)),
+				schema("e", 1, 5),
+			},
+			want: "reference cycle found: b/1 -> c/1 -> d/1 -> b/1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &State{SubjectSchemas: tt.subjectSchemas}
+			err := s.Sort()
+			if err == nil {
+				t.Fatal("expected a reference cycle")
+			}
+			if err.Error() != tt.want {
+				t.Errorf("got %q, want %q", err.Error(), tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateConflictingID(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(subjectSchema *sr.SubjectSchema)
+		want   string
+	}{
+		{
+			name:   "the same schema",
+			change: func(subjectSchema *sr.SubjectSchema) {},
+		},
+		{
+			name: "empty metadata is the same as none",
+			change: func(subjectSchema *sr.SubjectSchema) {
+				subjectSchema.SchemaMetadata = &sr.SchemaMetadata{}
+			},
+		},
+		{
+			name: "a different schema",
+			change: func(subjectSchema *sr.SubjectSchema) {
+				subjectSchema.Schema.Schema = `"int"`
+			},
+			want: "schema ID 1 is also used by subject a version 1 with a different schema",
+		},
+		{
+			name: "different metadata",
+			change: func(subjectSchema *sr.SubjectSchema) {
+				subjectSchema.SchemaMetadata = &sr.SchemaMetadata{Properties: map[string]string{"owner": "team"}}
+			},
+			want: "schema ID 1 is also used by subject a version 1 with different metadata",
+		},
+		{
+			name: "a different rule set",
+			change: func(subjectSchema *sr.SubjectSchema) {
+				subjectSchema.SchemaRuleSet = &sr.SchemaRuleSet{DomainRules: []sr.SchemaRule{{Name: "rule", Kind: sr.SchemaRuleKindCondition}}}
+			},
+			want: "schema ID 1 is also used by subject a version 1 with a different rule set",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := schema("b", 1, 1)
+			tt.change(&b)
+			s := &State{SubjectSchemas: []sr.SubjectSchema{schema("a", 1, 1), b}}
+			messages := make([]string, 0)
+			for _, problem := range s.Validate().Problems {
+				if problem.Check == "conflicting_id" {
+					messages = append(messages, problem.Message)
+				}
+			}
+			want := make([]string, 0)
+			if tt.want != "" {
+				want = append(want, tt.want)
+			}
+			if !slices.Equal(messages, want) {
+				t.Errorf("got %v, want %v", messages, want)
+			}
+		})
+	}
+}
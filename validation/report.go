@@ -0,0 +1,293 @@
+// Package validation collects the problems found in a migration into a report
+package validation
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+type Severity int
+
+const (
+	SeverityWarning Severity = iota
+	SeverityError
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityWarning:
+		return "WARNING"
+	case SeverityError:
+		return "ERROR"
+	default:
+		return ""
+	}
+}
+
+func (s Severity) MarshalText() ([]byte, error) {
+	text := s.String()
+	if text == "" {
+		return nil, fmt.Errorf("unknown severity %d", s)
+	}
+	return []byte(text), nil
+}
+
+// Problem is a single finding of a validation, optionally tied to a subject
+// version
+type Problem struct {
+	Severity Severity `json:"severity"`
+	Check    string   `json:"check"`
+	Subject  string   `json:"subject,omitempty"`
+	Version  int      `json:"version,omitempty"`
+	Message  string   `json:"message"`
+	Left     string   `json:"left,omitempty"`
+	Right    string   `json:"right,omitempty"`
+}
+
+func (p Problem) String() string {
+	location := ""
+	if p.Subject != "" {
+		location = fmt.Sprintf("subject %v: ", p.Subject)
+		if p.Version != 0 {
+			location = fmt.Sprintf("subject %v version %v: ", p.Subject, p.Version)
+		}
+	}
+	values := ""
+	if p.Left != "" || p.Right != "" {
+		// Long values such as schema text are only included in the structured formats
+		if len(p.Left) <= 80 && len(p.Right) <= 80 && !strings.ContainsAny(p.Left+p.Right, "\n") {
+			values = fmt.Sprintf(" (left: %v, right: %v)", p.Left, p.Right)
+		}
+	}
+	return fmt.Sprintf("%-7v %v: %v%v%v", p.Severity, p.Check, location, p.Message, values)
+}
+
+// Report collects every problem found while validating, rather than
+// stopping at the first. It satisfies error so that a process can hand back a
+// report when it finds errors.
+type Report struct {
+	Problems []Problem `json:"problems"`
+}
+
+func NewReport() *Report {
+	return &Report{Problems: make([]Problem, 0)}
+}
+
+func (r *Report) add(severity Severity, check string, subject string, version int, format string, args ...any) {
+	r.Problems = append(r.Problems, Problem{
+		Severity: severity,
+		Check:    check,
+		Subject:  subject,
+		Version:  version,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// Differs records an error for a value that Differs between the left and the
+// right of a comparison
+func (r *Report) Differs(check string, subject string, version int, message string, left any, right any) {
+	r.Problems = append(r.Problems, Problem{
+		Severity: SeverityError,
+		Check:    check,
+		Subject:  subject,
+		Version:  version,
+		Message:  message,
+		Left:     fmt.Sprintf("%v", left),
+		Right:    fmt.Sprintf("%v", right),
+	})
+}
+
+func (r *Report) Errorf(check string, subject string, version int, format string, args ...any) {
+	r.add(SeverityError, check, subject, version, format, args...)
+}
+
+func (r *Report) Warnf(check string, subject string, version int, format string, args ...any) {
+	r.add(SeverityWarning, check, subject, version, format, args...)
+}
+
+func (r *Report) Merge(other *Report) {
+	if other != nil {
+		r.Problems = append(r.Problems, other.Problems...)
+	}
+}
+
+func (r *Report) count(severity Severity) int {
+	count := 0
+	for _, problem := range r.Problems {
+		if problem.Severity == severity {
+			count++
+		}
+	}
+	return count
+}
+
+func (r *Report) HasErrors() bool {
+	return r.count(SeverityError) > 0
+}
+
+// Err returns the report as an error if it contains any errors, or nil if it
+// only contains warnings
+func (r *Report) Err() error {
+	if r.HasErrors() {
+		return r
+	}
+	return nil
+}
+
+func (r *Report) Error() string {
+	return fmt.Sprintf("validation found %v error(s) and %v warning(s)", r.count(SeverityError), r.count(SeverityWarning))
+}
+
+func (r *Report) Text() string {
+	var builder strings.Builder
+	for _, problem := range r.Problems {
+		builder.WriteString(problem.String())
+		builder.WriteString("\n")
+	}
+	builder.WriteString(fmt.Sprintf("%v error(s), %v warning(s)\n", r.count(SeverityError), r.count(SeverityWarning)))
+	return builder.String()
+}
+
+func (r *Report) JSON() ([]byte, error) {
+	return json.MarshalIndent(r, "", "  ")
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitTestSuite struct {
+	XMLName   xml.Name        `xml:"testsuite"`
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+// JUnit renders the report with one test case per subject that has problems,
+// failing if any of those problems are errors. A report without problems
+// renders as a single passing test case.
+func (r *Report) JUnit() ([]byte, error) {
+	suite := junitTestSuite{Name: "schema-migrator"}
+
+	subjects := make([]string, 0)
+	bySubject := make(map[string][]Problem)
+	for _, problem := range r.Problems {
+		if _, ok := bySubject[problem.Subject]; !ok {
+			subjects = append(subjects, problem.Subject)
+		}
+		bySubject[problem.Subject] = append(bySubject[problem.Subject], problem)
+	}
+
+	for _, subject := range subjects {
+		name := subject
+		if name == "" {
+			name = "(registry)"
+		}
+		testCase := junitTestCase{Name: name, ClassName: "schema-migrator"}
+		errs := make([]string, 0)
+		warnings := make([]string, 0)
+		checks := make([]string, 0)
+		for _, problem := range bySubject[subject] {
+			if problem.Severity == SeverityError {
+				errs = append(errs, problem.String())
+				if !slices.Contains(checks, problem.Check) {
+					checks = append(checks, problem.Check)
+				}
+			} else {
+				warnings = append(warnings, problem.String())
+			}
+		}
+		if len(errs) > 0 {
+			testCase.Failure = &junitFailure{
+				Message: fmt.Sprintf("%v error(s)", len(errs)),
+				Type:    strings.Join(checks, ","),
+				Text:    strings.Join(errs, "\n"),
+			}
+			suite.Failures++
+		}
+		testCase.SystemOut = strings.Join(warnings, "\n")
+		suite.TestCases = append(suite.TestCases, testCase)
+	}
+
+	if len(suite.TestCases) == 0 {
+		suite.TestCases = append(suite.TestCases, junitTestCase{Name: "validate", ClassName: "schema-migrator"})
+	}
+	suite.Tests = len(suite.TestCases)
+
+	data, err := xml.MarshalIndent(suite, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), data...), nil
+}
+
+// Describe renders structured values such as metadata for a report, falling
+// back to Go formatting if they can't be marshalled
+func Describe(value any) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(data)
+}
+
+// Reporter is implemented by sinks and processes that report on what they did,
+// such as anything that the target doesn't support, without failing
+type Reporter interface {
+	Report() *Report
+}
+
+type ReportConfig struct {
+	Format   string `koanf:"format"`
+	Filename string `koanf:"filename"`
+}
+
+// WriteReport renders the report in the configured format, to the configured
+// file or to stdout if there isn't one
+func WriteReport(reportConfig ReportConfig, report *Report) error {
+	var data []byte
+	switch strings.ToLower(reportConfig.Format) {
+	case "", "text":
+		data = []byte(report.Text())
+	case "json":
+		var err error
+		data, err = report.JSON()
+		if err != nil {
+			return fmt.Errorf("unable to marshal report into json: %w", err)
+		}
+		data = append(data, '\n')
+	case "junit":
+		var err error
+		data, err = report.JUnit()
+		if err != nil {
+			return fmt.Errorf("unable to marshal report into junit xml: %w", err)
+		}
+		data = append(data, '\n')
+	default:
+		return fmt.Errorf("unknown report format: %v", reportConfig.Format)
+	}
+
+	if reportConfig.Filename == "" {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+	err := os.WriteFile(reportConfig.Filename, data, 0644)
+	if err != nil {
+		return fmt.Errorf("unable to write file %v: %w", reportConfig.Filename, err)
+	}
+	return nil
+}
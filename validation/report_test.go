@@ -0,0 +1,82 @@
+package validation
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+// golden compares got with the named file in testdata, rewriting the file
+// instead when run with -update
+func golden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(path, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("%v doesn't match:\ngot:\n%s\nwant:\n%s", name, got, want)
+	}
+}
+
+func sampleReport() *Report {
+	report := NewReport()
+	report.Errorf("missing_right", "orders-value", 2, "not found in right")
+	report.Differs("id_mismatch", "orders-value", 1, "schema IDs don't match", 1, 10)
+	report.Warnf("metadata_dropped", "orders-value", 1, "metadata isn't supported by the target")
+	report.Differs("schema_mismatch", "payments-value", 1, "schemas don't match", `{"type":"record","name":"Payment","fields":[{"name":"amount","type":"double"},{"name":"currency","type":"string"}]}`, `{"type":"record","name":"Payment","fields":[]}`)
+	report.Warnf("mode_dropped", "customers-value", 0, "mode isn't supported by the target")
+	report.Differs("global_compatibility_mismatch", "", 0, "global compatibility levels don't match", "BACKWARD", "(none)")
+	return report
+}
+
+func TestReportText(t *testing.T) {
+	golden(t, "report.txt", []byte(sampleReport().Text()))
+}
+
+func TestReportJSON(t *testing.T) {
+	data, err := sampleReport().JSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden(t, "report.json", append(data, '\n'))
+}
+
+func TestReportJUnit(t *testing.T) {
+	data, err := sampleReport().JUnit()
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden(t, "report.xml", append(data, '\n'))
+}
+
+func TestReportJUnitEmpty(t *testing.T) {
+	data, err := NewReport().JUnit()
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden(t, "report_empty.xml", append(data, '\n'))
+}
+
+func TestReportErr(t *testing.T) {
+	report := NewReport()
+	report.Warnf("check", "", 0, "only a warning")
+	if report.Err() != nil {
+		t.Errorf("a report with only warnings shouldn't be an error")
+	}
+	report.Errorf("check", "", 0, "an error")
+	err := report.Err()
+	if err == nil || !strings.Contains(err.Error(), "1 error(s) and 1 warning(s)") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}